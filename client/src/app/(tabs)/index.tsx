@@ -32,6 +32,8 @@ export default function LeaderboardScreen() {
   const [hasMore, setHasMore] = useState(true);
   
   const offsetRef = useRef(0);
+  // ETag of the last first-page response, so polls can be answered with 304
+  const etagRef = useRef<string | null>(null);
 
   const fetchLeaderboard = async (isPoll = false, isRefresh = false) => {
     if (loadingMore && !isPoll && !isRefresh) return;
@@ -39,7 +41,19 @@ export default function LeaderboardScreen() {
     const currentOffset = isRefresh ? 0 : offsetRef.current;
     
     try {
-      const response = await fetch(`${API_URL}/leaderboard?limit=${PAGE_SIZE}&offset=${currentOffset}`);
+      const headers: Record<string, string> = {};
+      if (isPoll && currentOffset === 0 && etagRef.current) {
+        headers['If-None-Match'] = etagRef.current;
+      }
+
+      const response = await fetch(`${API_URL}/leaderboard?limit=${PAGE_SIZE}&offset=${currentOffset}`, { headers });
+      if (response.status === 304) {
+        // Nothing changed since the last poll
+        return;
+      }
+      if (currentOffset === 0) {
+        etagRef.current = response.headers.get('ETag');
+      }
       const data: User[] = await response.json();
       
       if (isRefresh || (isPoll && currentOffset === 0)) {
@@ -0,0 +1,1101 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        (unknown)
+// source: leaderboard/v1/leaderboard.proto
+
+package leaderboardv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type WatchRatingChangesResponse_Type int32
+
+const (
+	WatchRatingChangesResponse_TYPE_UNSPECIFIED    WatchRatingChangesResponse_Type = 0
+	WatchRatingChangesResponse_TYPE_USER_CREATED   WatchRatingChangesResponse_Type = 1
+	WatchRatingChangesResponse_TYPE_RATING_UPDATED WatchRatingChangesResponse_Type = 2
+	// TYPE_LEADERBOARD_RESET means the whole leaderboard was rebuilt, or
+	// that changes were missed because the client fell behind. Either way
+	// the client must fetch what it shows again.
+	WatchRatingChangesResponse_TYPE_LEADERBOARD_RESET WatchRatingChangesResponse_Type = 3
+)
+
+// Enum value maps for WatchRatingChangesResponse_Type.
+var (
+	WatchRatingChangesResponse_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_USER_CREATED",
+		2: "TYPE_RATING_UPDATED",
+		3: "TYPE_LEADERBOARD_RESET",
+	}
+	WatchRatingChangesResponse_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":       0,
+		"TYPE_USER_CREATED":      1,
+		"TYPE_RATING_UPDATED":    2,
+		"TYPE_LEADERBOARD_RESET": 3,
+	}
+)
+
+func (x WatchRatingChangesResponse_Type) Enum() *WatchRatingChangesResponse_Type {
+	p := new(WatchRatingChangesResponse_Type)
+	*p = x
+	return p
+}
+
+func (x WatchRatingChangesResponse_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (WatchRatingChangesResponse_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_leaderboard_v1_leaderboard_proto_enumTypes[0].Descriptor()
+}
+
+func (WatchRatingChangesResponse_Type) Type() protoreflect.EnumType {
+	return &file_leaderboard_v1_leaderboard_proto_enumTypes[0]
+}
+
+func (x WatchRatingChangesResponse_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use WatchRatingChangesResponse_Type.Descriptor instead.
+func (WatchRatingChangesResponse_Type) EnumDescriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{15, 0}
+}
+
+type User struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Rating   int64                  `protobuf:"varint,3,opt,name=rating,proto3" json:"rating,omitempty"`
+	// rank is 1 for the highest rating; users with equal ratings share a rank.
+	Rank          int64 `protobuf:"varint,4,opt,name=rank,proto3" json:"rank,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetRating() int64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *User) GetRank() int64 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+type CreateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Rating        int64                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUserRequest) Reset() {
+	*x = CreateUserRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUserRequest) ProtoMessage() {}
+
+func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
+func (*CreateUserRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CreateUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetRating() int64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type CreateUserResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUserResponse) Reset() {
+	*x = CreateUserResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUserResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUserResponse) ProtoMessage() {}
+
+func (x *CreateUserResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUserResponse.ProtoReflect.Descriptor instead.
+func (*CreateUserResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateUserResponse) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type UpdateRatingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Rating        int64                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRatingRequest) Reset() {
+	*x = UpdateRatingRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRatingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRatingRequest) ProtoMessage() {}
+
+func (x *UpdateRatingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRatingRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRatingRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *UpdateRatingRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *UpdateRatingRequest) GetRating() int64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type UpdateRatingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRatingResponse) Reset() {
+	*x = UpdateRatingResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRatingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRatingResponse) ProtoMessage() {}
+
+func (x *UpdateRatingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRatingResponse.ProtoReflect.Descriptor instead.
+func (*UpdateRatingResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{4}
+}
+
+type RatingUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Rating        int64                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RatingUpdate) Reset() {
+	*x = RatingUpdate{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RatingUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RatingUpdate) ProtoMessage() {}
+
+func (x *RatingUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RatingUpdate.ProtoReflect.Descriptor instead.
+func (*RatingUpdate) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RatingUpdate) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *RatingUpdate) GetRating() int64 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+type BatchUpdateRatingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updates       []*RatingUpdate        `protobuf:"bytes,1,rep,name=updates,proto3" json:"updates,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchUpdateRatingsRequest) Reset() {
+	*x = BatchUpdateRatingsRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchUpdateRatingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchUpdateRatingsRequest) ProtoMessage() {}
+
+func (x *BatchUpdateRatingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchUpdateRatingsRequest.ProtoReflect.Descriptor instead.
+func (*BatchUpdateRatingsRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchUpdateRatingsRequest) GetUpdates() []*RatingUpdate {
+	if x != nil {
+		return x.Updates
+	}
+	return nil
+}
+
+type BatchUpdateRatingsResponse struct {
+	state         protoimpl.MessageState               `protogen:"open.v1"`
+	Results       []*BatchUpdateRatingsResponse_Result `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchUpdateRatingsResponse) Reset() {
+	*x = BatchUpdateRatingsResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchUpdateRatingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchUpdateRatingsResponse) ProtoMessage() {}
+
+func (x *BatchUpdateRatingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchUpdateRatingsResponse.ProtoReflect.Descriptor instead.
+func (*BatchUpdateRatingsResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchUpdateRatingsResponse) GetResults() []*BatchUpdateRatingsResponse_Result {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type GetLeaderboardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLeaderboardRequest) Reset() {
+	*x = GetLeaderboardRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLeaderboardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLeaderboardRequest) ProtoMessage() {}
+
+func (x *GetLeaderboardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLeaderboardRequest.ProtoReflect.Descriptor instead.
+func (*GetLeaderboardRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetLeaderboardRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *GetLeaderboardRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type GetLeaderboardResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Version       int64                  `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetLeaderboardResponse) Reset() {
+	*x = GetLeaderboardResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetLeaderboardResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetLeaderboardResponse) ProtoMessage() {}
+
+func (x *GetLeaderboardResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetLeaderboardResponse.ProtoReflect.Descriptor instead.
+func (*GetLeaderboardResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetLeaderboardResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *GetLeaderboardResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+type SearchUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchUsersRequest) Reset() {
+	*x = SearchUsersRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchUsersRequest) ProtoMessage() {}
+
+func (x *SearchUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchUsersRequest.ProtoReflect.Descriptor instead.
+func (*SearchUsersRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SearchUsersRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+type SearchUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchUsersResponse) Reset() {
+	*x = SearchUsersResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchUsersResponse) ProtoMessage() {}
+
+func (x *SearchUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchUsersResponse.ProtoReflect.Descriptor instead.
+func (*SearchUsersResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SearchUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+type GetUserRankRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRankRequest) Reset() {
+	*x = GetUserRankRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRankRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRankRequest) ProtoMessage() {}
+
+func (x *GetUserRankRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRankRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRankRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetUserRankRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type GetUserRankResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRankResponse) Reset() {
+	*x = GetUserRankResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRankResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRankResponse) ProtoMessage() {}
+
+func (x *GetUserRankResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRankResponse.ProtoReflect.Descriptor instead.
+func (*GetUserRankResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetUserRankResponse) GetUser() *User {
+	if x != nil {
+		return x.User
+	}
+	return nil
+}
+
+type WatchRatingChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// user_ids restricts the stream to these users; empty streams every change.
+	UserIds       []int64 `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRatingChangesRequest) Reset() {
+	*x = WatchRatingChangesRequest{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRatingChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRatingChangesRequest) ProtoMessage() {}
+
+func (x *WatchRatingChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRatingChangesRequest.ProtoReflect.Descriptor instead.
+func (*WatchRatingChangesRequest) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchRatingChangesRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type WatchRatingChangesResponse struct {
+	state         protoimpl.MessageState          `protogen:"open.v1"`
+	Type          WatchRatingChangesResponse_Type `protobuf:"varint,1,opt,name=type,proto3,enum=leaderboard.v1.WatchRatingChangesResponse_Type" json:"type,omitempty"`
+	Version       int64                           `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	UserId        int64                           `protobuf:"varint,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Username      string                          `protobuf:"bytes,4,opt,name=username,proto3" json:"username,omitempty"`
+	OldRating     int64                           `protobuf:"varint,5,opt,name=old_rating,json=oldRating,proto3" json:"old_rating,omitempty"`
+	NewRating     int64                           `protobuf:"varint,6,opt,name=new_rating,json=newRating,proto3" json:"new_rating,omitempty"`
+	Time          *timestamppb.Timestamp          `protobuf:"bytes,7,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRatingChangesResponse) Reset() {
+	*x = WatchRatingChangesResponse{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRatingChangesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRatingChangesResponse) ProtoMessage() {}
+
+func (x *WatchRatingChangesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRatingChangesResponse.ProtoReflect.Descriptor instead.
+func (*WatchRatingChangesResponse) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchRatingChangesResponse) GetType() WatchRatingChangesResponse_Type {
+	if x != nil {
+		return x.Type
+	}
+	return WatchRatingChangesResponse_TYPE_UNSPECIFIED
+}
+
+func (x *WatchRatingChangesResponse) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *WatchRatingChangesResponse) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *WatchRatingChangesResponse) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *WatchRatingChangesResponse) GetOldRating() int64 {
+	if x != nil {
+		return x.OldRating
+	}
+	return 0
+}
+
+func (x *WatchRatingChangesResponse) GetNewRating() int64 {
+	if x != nil {
+		return x.NewRating
+	}
+	return 0
+}
+
+func (x *WatchRatingChangesResponse) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+type BatchUpdateRatingsResponse_Result struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// error is empty when the update was applied.
+	Error         string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchUpdateRatingsResponse_Result) Reset() {
+	*x = BatchUpdateRatingsResponse_Result{}
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchUpdateRatingsResponse_Result) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchUpdateRatingsResponse_Result) ProtoMessage() {}
+
+func (x *BatchUpdateRatingsResponse_Result) ProtoReflect() protoreflect.Message {
+	mi := &file_leaderboard_v1_leaderboard_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchUpdateRatingsResponse_Result.ProtoReflect.Descriptor instead.
+func (*BatchUpdateRatingsResponse_Result) Descriptor() ([]byte, []int) {
+	return file_leaderboard_v1_leaderboard_proto_rawDescGZIP(), []int{7, 0}
+}
+
+func (x *BatchUpdateRatingsResponse_Result) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *BatchUpdateRatingsResponse_Result) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_leaderboard_v1_leaderboard_proto protoreflect.FileDescriptor
+
+const file_leaderboard_v1_leaderboard_proto_rawDesc = "" +
+	"\n" +
+	" leaderboard/v1/leaderboard.proto\x12\x0eleaderboard.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"^\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x16\n" +
+	"\x06rating\x18\x03 \x01(\x03R\x06rating\x12\x12\n" +
+	"\x04rank\x18\x04 \x01(\x03R\x04rank\"G\n" +
+	"\x11CreateUserRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x03R\x06rating\">\n" +
+	"\x12CreateUserResponse\x12(\n" +
+	"\x04user\x18\x01 \x01(\v2\x14.leaderboard.v1.UserR\x04user\"F\n" +
+	"\x13UpdateRatingRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x03R\x06rating\"\x16\n" +
+	"\x14UpdateRatingResponse\"?\n" +
+	"\fRatingUpdate\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x03R\x06rating\"S\n" +
+	"\x19BatchUpdateRatingsRequest\x126\n" +
+	"\aupdates\x18\x01 \x03(\v2\x1c.leaderboard.v1.RatingUpdateR\aupdates\"\xa2\x01\n" +
+	"\x1aBatchUpdateRatingsResponse\x12K\n" +
+	"\aresults\x18\x01 \x03(\v21.leaderboard.v1.BatchUpdateRatingsResponse.ResultR\aresults\x1a7\n" +
+	"\x06Result\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"E\n" +
+	"\x15GetLeaderboardRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"^\n" +
+	"\x16GetLeaderboardResponse\x12*\n" +
+	"\x05users\x18\x01 \x03(\v2\x14.leaderboard.v1.UserR\x05users\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"0\n" +
+	"\x12SearchUsersRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\"A\n" +
+	"\x13SearchUsersResponse\x12*\n" +
+	"\x05users\x18\x01 \x03(\v2\x14.leaderboard.v1.UserR\x05users\"-\n" +
+	"\x12GetUserRankRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"?\n" +
+	"\x13GetUserRankResponse\x12(\n" +
+	"\x04user\x18\x01 \x01(\v2\x14.leaderboard.v1.UserR\x04user\"6\n" +
+	"\x19WatchRatingChangesRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\"\x88\x03\n" +
+	"\x1aWatchRatingChangesResponse\x12C\n" +
+	"\x04type\x18\x01 \x01(\x0e2/.leaderboard.v1.WatchRatingChangesResponse.TypeR\x04type\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\x03R\x06userId\x12\x1a\n" +
+	"\busername\x18\x04 \x01(\tR\busername\x12\x1d\n" +
+	"\n" +
+	"old_rating\x18\x05 \x01(\x03R\toldRating\x12\x1d\n" +
+	"\n" +
+	"new_rating\x18\x06 \x01(\x03R\tnewRating\x12.\n" +
+	"\x04time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x04time\"h\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11TYPE_USER_CREATED\x10\x01\x12\x17\n" +
+	"\x13TYPE_RATING_UPDATED\x10\x02\x12\x1a\n" +
+	"\x16TYPE_LEADERBOARD_RESET\x10\x032\xb1\x05\n" +
+	"\x12LeaderboardService\x12S\n" +
+	"\n" +
+	"CreateUser\x12!.leaderboard.v1.CreateUserRequest\x1a\".leaderboard.v1.CreateUserResponse\x12Y\n" +
+	"\fUpdateRating\x12#.leaderboard.v1.UpdateRatingRequest\x1a$.leaderboard.v1.UpdateRatingResponse\x12k\n" +
+	"\x12BatchUpdateRatings\x12).leaderboard.v1.BatchUpdateRatingsRequest\x1a*.leaderboard.v1.BatchUpdateRatingsResponse\x12_\n" +
+	"\x0eGetLeaderboard\x12%.leaderboard.v1.GetLeaderboardRequest\x1a&.leaderboard.v1.GetLeaderboardResponse\x12V\n" +
+	"\vSearchUsers\x12\".leaderboard.v1.SearchUsersRequest\x1a#.leaderboard.v1.SearchUsersResponse\x12V\n" +
+	"\vGetUserRank\x12\".leaderboard.v1.GetUserRankRequest\x1a#.leaderboard.v1.GetUserRankResponse\x12m\n" +
+	"\x12WatchRatingChanges\x12).leaderboard.v1.WatchRatingChangesRequest\x1a*.leaderboard.v1.WatchRatingChangesResponse0\x01B.Z,leaderboard/api/leaderboard/v1;leaderboardv1b\x06proto3"
+
+var (
+	file_leaderboard_v1_leaderboard_proto_rawDescOnce sync.Once
+	file_leaderboard_v1_leaderboard_proto_rawDescData []byte
+)
+
+func file_leaderboard_v1_leaderboard_proto_rawDescGZIP() []byte {
+	file_leaderboard_v1_leaderboard_proto_rawDescOnce.Do(func() {
+		file_leaderboard_v1_leaderboard_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_leaderboard_v1_leaderboard_proto_rawDesc), len(file_leaderboard_v1_leaderboard_proto_rawDesc)))
+	})
+	return file_leaderboard_v1_leaderboard_proto_rawDescData
+}
+
+var file_leaderboard_v1_leaderboard_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_leaderboard_v1_leaderboard_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_leaderboard_v1_leaderboard_proto_goTypes = []any{
+	(WatchRatingChangesResponse_Type)(0),      // 0: leaderboard.v1.WatchRatingChangesResponse.Type
+	(*User)(nil),                              // 1: leaderboard.v1.User
+	(*CreateUserRequest)(nil),                 // 2: leaderboard.v1.CreateUserRequest
+	(*CreateUserResponse)(nil),                // 3: leaderboard.v1.CreateUserResponse
+	(*UpdateRatingRequest)(nil),               // 4: leaderboard.v1.UpdateRatingRequest
+	(*UpdateRatingResponse)(nil),              // 5: leaderboard.v1.UpdateRatingResponse
+	(*RatingUpdate)(nil),                      // 6: leaderboard.v1.RatingUpdate
+	(*BatchUpdateRatingsRequest)(nil),         // 7: leaderboard.v1.BatchUpdateRatingsRequest
+	(*BatchUpdateRatingsResponse)(nil),        // 8: leaderboard.v1.BatchUpdateRatingsResponse
+	(*GetLeaderboardRequest)(nil),             // 9: leaderboard.v1.GetLeaderboardRequest
+	(*GetLeaderboardResponse)(nil),            // 10: leaderboard.v1.GetLeaderboardResponse
+	(*SearchUsersRequest)(nil),                // 11: leaderboard.v1.SearchUsersRequest
+	(*SearchUsersResponse)(nil),               // 12: leaderboard.v1.SearchUsersResponse
+	(*GetUserRankRequest)(nil),                // 13: leaderboard.v1.GetUserRankRequest
+	(*GetUserRankResponse)(nil),               // 14: leaderboard.v1.GetUserRankResponse
+	(*WatchRatingChangesRequest)(nil),         // 15: leaderboard.v1.WatchRatingChangesRequest
+	(*WatchRatingChangesResponse)(nil),        // 16: leaderboard.v1.WatchRatingChangesResponse
+	(*BatchUpdateRatingsResponse_Result)(nil), // 17: leaderboard.v1.BatchUpdateRatingsResponse.Result
+	(*timestamppb.Timestamp)(nil),             // 18: google.protobuf.Timestamp
+}
+var file_leaderboard_v1_leaderboard_proto_depIdxs = []int32{
+	1,  // 0: leaderboard.v1.CreateUserResponse.user:type_name -> leaderboard.v1.User
+	6,  // 1: leaderboard.v1.BatchUpdateRatingsRequest.updates:type_name -> leaderboard.v1.RatingUpdate
+	17, // 2: leaderboard.v1.BatchUpdateRatingsResponse.results:type_name -> leaderboard.v1.BatchUpdateRatingsResponse.Result
+	1,  // 3: leaderboard.v1.GetLeaderboardResponse.users:type_name -> leaderboard.v1.User
+	1,  // 4: leaderboard.v1.SearchUsersResponse.users:type_name -> leaderboard.v1.User
+	1,  // 5: leaderboard.v1.GetUserRankResponse.user:type_name -> leaderboard.v1.User
+	0,  // 6: leaderboard.v1.WatchRatingChangesResponse.type:type_name -> leaderboard.v1.WatchRatingChangesResponse.Type
+	18, // 7: leaderboard.v1.WatchRatingChangesResponse.time:type_name -> google.protobuf.Timestamp
+	2,  // 8: leaderboard.v1.LeaderboardService.CreateUser:input_type -> leaderboard.v1.CreateUserRequest
+	4,  // 9: leaderboard.v1.LeaderboardService.UpdateRating:input_type -> leaderboard.v1.UpdateRatingRequest
+	7,  // 10: leaderboard.v1.LeaderboardService.BatchUpdateRatings:input_type -> leaderboard.v1.BatchUpdateRatingsRequest
+	9,  // 11: leaderboard.v1.LeaderboardService.GetLeaderboard:input_type -> leaderboard.v1.GetLeaderboardRequest
+	11, // 12: leaderboard.v1.LeaderboardService.SearchUsers:input_type -> leaderboard.v1.SearchUsersRequest
+	13, // 13: leaderboard.v1.LeaderboardService.GetUserRank:input_type -> leaderboard.v1.GetUserRankRequest
+	15, // 14: leaderboard.v1.LeaderboardService.WatchRatingChanges:input_type -> leaderboard.v1.WatchRatingChangesRequest
+	3,  // 15: leaderboard.v1.LeaderboardService.CreateUser:output_type -> leaderboard.v1.CreateUserResponse
+	5,  // 16: leaderboard.v1.LeaderboardService.UpdateRating:output_type -> leaderboard.v1.UpdateRatingResponse
+	8,  // 17: leaderboard.v1.LeaderboardService.BatchUpdateRatings:output_type -> leaderboard.v1.BatchUpdateRatingsResponse
+	10, // 18: leaderboard.v1.LeaderboardService.GetLeaderboard:output_type -> leaderboard.v1.GetLeaderboardResponse
+	12, // 19: leaderboard.v1.LeaderboardService.SearchUsers:output_type -> leaderboard.v1.SearchUsersResponse
+	14, // 20: leaderboard.v1.LeaderboardService.GetUserRank:output_type -> leaderboard.v1.GetUserRankResponse
+	16, // 21: leaderboard.v1.LeaderboardService.WatchRatingChanges:output_type -> leaderboard.v1.WatchRatingChangesResponse
+	15, // [15:22] is the sub-list for method output_type
+	8,  // [8:15] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_leaderboard_v1_leaderboard_proto_init() }
+func file_leaderboard_v1_leaderboard_proto_init() {
+	if File_leaderboard_v1_leaderboard_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_leaderboard_v1_leaderboard_proto_rawDesc), len(file_leaderboard_v1_leaderboard_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_leaderboard_v1_leaderboard_proto_goTypes,
+		DependencyIndexes: file_leaderboard_v1_leaderboard_proto_depIdxs,
+		EnumInfos:         file_leaderboard_v1_leaderboard_proto_enumTypes,
+		MessageInfos:      file_leaderboard_v1_leaderboard_proto_msgTypes,
+	}.Build()
+	File_leaderboard_v1_leaderboard_proto = out.File
+	file_leaderboard_v1_leaderboard_proto_goTypes = nil
+	file_leaderboard_v1_leaderboard_proto_depIdxs = nil
+}
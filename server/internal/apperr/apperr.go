@@ -0,0 +1,145 @@
+// Package apperr defines the errors services and repositories return for
+// failures the caller can act on. Each one has a kind, which transports map
+// to a status code, and a stable machine-readable code.
+package apperr
+
+import "errors"
+
+type Kind int
+
+const (
+	// KindInternal is any failure without a more specific kind.
+	KindInternal Kind = iota
+	// KindValidation means the input was rejected.
+	KindValidation
+	// KindNotFound means the requested record doesn't exist.
+	KindNotFound
+	// KindConflict means the write clashes with existing data.
+	KindConflict
+	// KindPrecondition means the write was conditional on state that has
+	// since changed.
+	KindPrecondition
+	// KindUnavailable means a backing service can't be reached; retrying
+	// later may succeed.
+	KindUnavailable
+	// KindTimeout means the operation didn't finish before its deadline.
+	KindTimeout
+	// KindCanceled means the caller gave up on the operation.
+	KindCanceled
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindValidation:
+		return "validation"
+	case KindNotFound:
+		return "not found"
+	case KindConflict:
+		return "conflict"
+	case KindPrecondition:
+		return "precondition failed"
+	case KindUnavailable:
+		return "unavailable"
+	case KindTimeout:
+		return "timeout"
+	case KindCanceled:
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
+
+// Error is a failure of a known kind.
+type Error struct {
+	Kind Kind
+	// Code identifies the failure, such as "username_taken". Clients may
+	// rely on it, so codes never change once published.
+	Code    string
+	Message string
+	// Err is the underlying cause, if any.
+	Err error
+	// Fields lists what is wrong with each invalid input field of a
+	// validation error.
+	Fields []FieldError
+}
+
+// FieldError describes one problem with one input field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors of the same kind and code, so sentinel errors still
+// match after Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func Validation(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+// InvalidFields returns a validation error listing the problems of each
+// field.
+func InvalidFields(code, message string, fields []FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return &Error{Kind: KindPrecondition, Code: code, Message: message}
+}
+
+func Unavailable(code, message string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message, Err: err}
+}
+
+func Timeout(code, message string) *Error {
+	return &Error{Kind: KindTimeout, Code: code, Message: message}
+}
+
+func Canceled(code, message string) *Error {
+	return &Error{Kind: KindCanceled, Code: code, Message: message}
+}
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// KindOf returns the kind of err, KindInternal for errors of no known kind.
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// Role grants access to a group of routes. Roles are ordered: each one
+// includes everything the previous one allows.
+type Role string
+
+const (
+	RoleReader      Role = "reader"
+	RoleScoreWriter Role = "score-writer"
+	RoleAdmin       Role = "admin"
+)
+
+var roleLevels = map[Role]int{
+	RoleReader:      1,
+	RoleScoreWriter: 2,
+	RoleAdmin:       3,
+}
+
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+// Allows reports whether r grants at least the access of required.
+func (r Role) Allows(required Role) bool {
+	return roleLevels[r] >= roleLevels[required]
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	// KeyID is the ID of the API key used, or 0 for the bootstrap key and
+	// player tokens.
+	KeyID int
+	Name  string
+	Role  Role
+	// UserID is the user a player token was issued to, or 0 for API keys.
+	UserID int
+}
+
+// IsPlayer reports whether p authenticated with a player token.
+func (p *Principal) IsPlayer() bool {
+	return p.UserID != 0
+}
+
+// ClientKey identifies p among the clients counted by rate limits: by
+// user for players, by API key otherwise.
+func (p *Principal) ClientKey() string {
+	if p.IsPlayer() {
+		return "user:" + strconv.Itoa(p.UserID)
+	}
+	if p.KeyID == 0 {
+		// Keys that aren't stored, like the bootstrap key, have no ID.
+		return "key:" + p.Name
+	}
+	return "key:" + strconv.Itoa(p.KeyID)
+}
+
+// CanActFor reports whether p may change the data of the given user. API
+// keys act for every user, players only for themselves.
+func (p *Principal) CanActFor(userID int) bool {
+	return !p.IsPlayer() || p.UserID == userID
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok
+}
@@ -0,0 +1,189 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+// testKeys are the keys tokens are signed with, and the files their public
+// halves are configured from.
+type testKeys struct {
+	rsa     *rsa.PrivateKey
+	ec      *ecdsa.PrivateKey
+	pemFile string
+	jwks    string
+}
+
+func newTestKeys(t *testing.T) testKeys {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	b64 := base64.RawURLEncoding.EncodeToString
+	point, err := ecKey.PublicKey.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks, err := json.Marshal(map[string]any{"keys": []jwk{
+		{Kty: "EC", Kid: "ec-1", Use: "sig", Crv: "P-256", X: b64(point[1:33]), Y: b64(point[33:])},
+		{Kty: "RSA", Kid: "enc-1", Use: "enc", N: b64(rsaKey.N.Bytes()), E: "AQAB"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwksFile := filepath.Join(dir, "jwks.json")
+	if err := os.WriteFile(jwksFile, jwks, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeys{rsa: rsaKey, ec: ecKey, pemFile: pemFile, jwks: jwksFile}
+}
+
+func claims(subject string, expires time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(expires)),
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, c jwt.Claims, kid string, key any) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestTokenVerifier(t *testing.T) {
+	keys := newTestKeys(t)
+	hmacOnly := TokenConfig{HMACSecret: testSecret}
+	publicKeys := TokenConfig{PublicKeyFile: keys.pemFile, JWKSFile: keys.jwks}
+
+	withAudience := claims("7", time.Hour)
+	withAudience.Issuer = "https://issuer.example"
+	withAudience.Audience = jwt.ClaimStrings{"leaderboard"}
+
+	tests := []struct {
+		name     string
+		cfg      TokenConfig
+		token    string
+		wantUser int
+	}{
+		{name: "hmac", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("42", time.Hour), "", []byte(testSecret)), wantUser: 42},
+		{name: "hmac wrong secret", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("42", time.Hour), "", []byte("other"))},
+		{name: "expired", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("42", -time.Hour), "", []byte(testSecret))},
+		{name: "expired within leeway", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("42", -10*time.Second), "", []byte(testSecret)), wantUser: 42},
+		{name: "no expiry", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, jwt.RegisteredClaims{Subject: "42"}, "", []byte(testSecret))},
+		{name: "subject not a user ID", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("alice", time.Hour), "", []byte(testSecret))},
+		{name: "subject zero", cfg: hmacOnly, token: sign(t, jwt.SigningMethodHS256, claims("0", time.Hour), "", []byte(testSecret))},
+		{name: "unsigned", cfg: hmacOnly, token: sign(t, jwt.SigningMethodNone, claims("42", time.Hour), "", jwt.UnsafeAllowNoneSignatureType)},
+		{name: "not a token", cfg: hmacOnly, token: "not.a.token"},
+		{name: "rsa public key", cfg: publicKeys, token: sign(t, jwt.SigningMethodRS256, claims("5", time.Hour), "", keys.rsa), wantUser: 5},
+		{name: "ec from jwks", cfg: publicKeys, token: sign(t, jwt.SigningMethodES256, claims("6", time.Hour), "ec-1", keys.ec), wantUser: 6},
+		{name: "ec without kid", cfg: publicKeys, token: sign(t, jwt.SigningMethodES256, claims("6", time.Hour), "", keys.ec)},
+		{name: "encryption key ignored", cfg: TokenConfig{JWKSFile: keys.jwks}, token: sign(t, jwt.SigningMethodRS256, claims("5", time.Hour), "enc-1", keys.rsa)},
+		{name: "single jwks key without kid", cfg: TokenConfig{JWKSFile: keys.jwks}, token: sign(t, jwt.SigningMethodES256, claims("6", time.Hour), "", keys.ec), wantUser: 6},
+		{name: "hmac not accepted", cfg: publicKeys, token: sign(t, jwt.SigningMethodHS256, claims("42", time.Hour), "", []byte(testSecret))},
+		{
+			name:     "issuer and audience",
+			cfg:      TokenConfig{HMACSecret: testSecret, Issuer: "https://issuer.example", Audience: "leaderboard"},
+			token:    sign(t, jwt.SigningMethodHS256, withAudience, "", []byte(testSecret)),
+			wantUser: 7,
+		},
+		{
+			name:  "wrong audience",
+			cfg:   TokenConfig{HMACSecret: testSecret, Audience: "other"},
+			token: sign(t, jwt.SigningMethodHS256, withAudience, "", []byte(testSecret)),
+		},
+		{
+			name:  "missing issuer",
+			cfg:   TokenConfig{HMACSecret: testSecret, Issuer: "https://issuer.example"},
+			token: sign(t, jwt.SigningMethodHS256, claims("7", time.Hour), "", []byte(testSecret)),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := NewTokenVerifier(tt.cfg)
+			if err != nil {
+				t.Fatalf("NewTokenVerifier failed: %v", err)
+			}
+
+			p, err := v.Verify(tt.token)
+			if tt.wantUser == 0 {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			if p.UserID != tt.wantUser || p.Role != RoleReader || !p.IsPlayer() {
+				t.Errorf("Verify = %+v, want reader player %d", p, tt.wantUser)
+			}
+		})
+	}
+}
+
+func TestNewTokenVerifierErrors(t *testing.T) {
+	dir := t.TempDir()
+	garbage := filepath.Join(dir, "garbage")
+	if err := os.WriteFile(garbage, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		cfg  TokenConfig
+	}{
+		{name: "no keys", cfg: TokenConfig{}},
+		{name: "missing public key file", cfg: TokenConfig{PublicKeyFile: filepath.Join(dir, "missing.pem")}},
+		{name: "not a PEM file", cfg: TokenConfig{PublicKeyFile: garbage}},
+		{name: "not a JWKS file", cfg: TokenConfig{JWKSFile: garbage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewTokenVerifier(tt.cfg); err == nil {
+				t.Error("NewTokenVerifier succeeded, want an error")
+			}
+		})
+	}
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
+	"os"
 	"time"
 
 	"leaderboard/internal/config"
 	"leaderboard/internal/database"
+	"leaderboard/internal/logging"
 	"leaderboard/internal/models"
+	"leaderboard/internal/username"
 
 	"gorm.io/gorm"
 )
@@ -24,22 +27,28 @@ func main() {
 	rand.New(rand.NewSource(time.Now().UnixNano()))
 
 	// 1. Load config
-	cfg := config.Load()
+	cfg := config.MustLoad()
+	logging.Setup(logging.NewHandler(os.Stderr, cfg.LogFormat), logging.Levels{Default: cfg.LogLevel, Components: cfg.LogLevels})
 
 	// 2. Connect to DB
 	db := database.New(cfg)
 
 	// 3. Ensure table exists (STRICT, explicit)
 	if err := ensureSchema(db); err != nil {
-		log.Fatal(err)
+		fatal("Failed to create schema", err)
 	}
 
 	// 4. Seed users
 	if err := seedUsers(db); err != nil {
-		log.Fatal(err)
+		fatal("Failed to seed users", err)
 	}
 
-	log.Println("✅ Database seeding completed successfully")
+	slog.Info("Database seeding completed")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
 
 func ensureSchema(db *gorm.DB) error {
@@ -50,7 +59,9 @@ func ensureSchema(db *gorm.DB) error {
 	CREATE TABLE IF NOT EXISTS users (
 		id INT PRIMARY KEY,
 		username TEXT UNIQUE NOT NULL,
+		username_normalized TEXT UNIQUE NOT NULL,
 		rating INT NOT NULL,
+		version INT NOT NULL DEFAULT 1,
 		created_at TIMESTAMP NOT NULL DEFAULT NOW(),
 		updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 	);
@@ -63,15 +74,17 @@ func ensureSchema(db *gorm.DB) error {
 }
 
 func seedUsers(db *gorm.DB) error {
-	log.Printf("Seeding %d users...\n", totalUsers)
+	slog.Info("Seeding users", "count", totalUsers)
 
 	users := make([]models.User, 0, batchSize)
 
 	for i := 1; i <= totalUsers; i++ {
+		name := fmt.Sprintf("user_%05d", i)
 		user := models.User{
-			ID:       i,
-			Username: fmt.Sprintf("user_%05d", i),
-			Rating:   rand.Intn(maxRating-minRating+1) + minRating,
+			ID:                 i,
+			Username:           name,
+			UsernameNormalized: username.Canonical(name),
+			Rating:             rand.Intn(maxRating-minRating+1) + minRating,
 		}
 
 		users = append(users, user)
@@ -1,57 +1,664 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	leaderboardv1 "leaderboard/api/leaderboard/v1"
+	"leaderboard/internal/auth"
 	"leaderboard/internal/config"
 	"leaderboard/internal/database"
+	"leaderboard/internal/events"
+	"leaderboard/internal/grpcserver"
 	"leaderboard/internal/handlers"
+	"leaderboard/internal/health"
+	"leaderboard/internal/idempotency"
+	"leaderboard/internal/lifecycle"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/metrics"
+	"leaderboard/internal/middleware"
+	"leaderboard/internal/openapi"
+	"leaderboard/internal/ratelimit"
 	"leaderboard/internal/repository"
 	"leaderboard/internal/services"
-	"log"
+	"leaderboard/internal/tracing"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/gorm"
 )
 
 func main() {
-	cfg := config.Load()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	// Configuration problems are printed as they are, one per line, rather
+	// than logged.
+	if cfg != nil && cfg.PrintConfig {
+		if printErr := cfg.Print(os.Stdout); printErr != nil {
+			exit(printErr)
+		}
+		// The configuration is printed even when invalid, to help fix it.
+		if err != nil {
+			exit(err)
+		}
+		return
+	}
+	if err != nil {
+		exit(err)
+	}
+	// Logs carry the IDs of the request and trace they were written in,
+	// if any.
+	logging.Setup(tracing.NewLogHandler(logging.NewHandler(os.Stderr, cfg.LogFormat)), logLevels(cfg))
+	reloader := config.NewReloader(cfg, os.Args[1:])
+
+	// Components are added to the lifecycle as they are built, so they stop
+	// in the reverse order: servers first, connections last.
+	app := lifecycle.New()
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
+	// Added first so the spans of the shutdown itself are exported.
+	app.Add(lifecycle.Component{Name: "tracing", Stop: shutdownTracing})
 
 	db := database.New(cfg)
+	if err := database.Migrate(db); err != nil {
+		fatal("Failed to migrate database", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		fatal("Failed to get database handle", err)
+	}
+	app.Add(lifecycle.Component{Name: "Postgres", Stop: func(context.Context) error { return sqlDB.Close() }})
+
 	rdb := database.NewRedis(cfg)
+	if rdb != nil {
+		app.Add(lifecycle.Component{Name: "Redis", Stop: func(context.Context) error { return rdb.Close() }})
+	}
 
-	userRepo := repository.NewPostgresUserRepository(db, rdb)
+	bus := newEventBus(rdb)
+	app.Add(lifecycle.Component{Name: "event bus", Stop: func(context.Context) error { return bus.Close() }})
 
-	leaderboardService := services.NewLeaderboardService(userRepo)
+	postgresRepo := repository.NewPostgresUserRepository(db, rdb, bus, queryTimeouts(cfg))
+	app.Add(lifecycle.Component{Name: "Redis sync", Stop: postgresRepo.Close})
+	metrics.Registry.MustRegister(
+		collectors.NewDBStatsCollector(sqlDB, "leaderboard"),
+		metrics.NewSizeCollector(postgresRepo.Size),
+	)
 
-	simulationService := services.NewSimulationService(userRepo)
-	simulationService.Start() // Start automatically on boot
+	var userRepo repository.UserRepository = postgresRepo
+	var cache *repository.CachedUserRepository
+	if cfg.LeaderboardCacheSize > 0 {
+		cache = repository.NewCachedUserRepository(userRepo, bus, cfg.LeaderboardCacheSize, cfg.LeaderboardCacheMaxStale)
+		userRepo = cache
+		app.Add(lifecycle.Component{Name: "leaderboard cache", Stop: func(context.Context) error {
+			cache.Close()
+			return nil
+		}})
+	}
 
-	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, simulationService)
+	leaderboardService := services.NewLeaderboardService(userRepo, cfg.UsernamePolicy)
+
+	maintenance := services.NewMaintenance()
+	simulationService := services.NewSimulationService(userRepo, maintenance, simulationConfig(cfg))
+	app.Add(lifecycle.Component{
+		Name: "simulation",
+		Start: func() error {
+			if cfg.Simulation.Autostart {
+				simulationService.Start()
+			}
+			return nil
+		},
+		Stop: func(context.Context) error {
+			simulationService.Stop()
+			return nil
+		},
+	})
+
+	topWatcher := services.NewTopWatcher(userRepo, bus, cfg.LongPollMaxWaiters)
+	app.Add(lifecycle.Component{
+		Name:  "top watcher",
+		Start: func() error { topWatcher.Start(); return nil },
+		Stop: func(context.Context) error {
+			topWatcher.Stop()
+			return nil
+		},
+	})
+
+	apiKeyRepo := repository.NewPostgresAPIKeyRepository(db, queryTimeouts(cfg))
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, cfg.AdminAPIKey)
+	tokenVerifier := newTokenVerifier(cfg)
+
+	auditRepo := repository.NewPostgresAuditRepository(db, queryTimeouts(cfg))
+	auditService := services.NewAuditService(auditRepo)
+	operationsService := services.NewOperationsService(postgresRepo, cache, sqlDB, rdb)
+
+	leaderboardHandler := handlers.NewLeaderboardHandler(leaderboardService, topWatcher)
+	adminHandler := handlers.NewAdminHandler(apiKeyService)
+	operationsHandler := handlers.NewOperationsHandler(simulationService, operationsService, maintenance, auditService)
+	configHandler := handlers.NewConfigHandler(reloader)
+	checker := newHealthChecker(cfg, db, sqlDB, rdb, postgresRepo)
+	healthHandler := handlers.NewHealthHandler(checker)
+
+	spec, err := openapi.Load()
+	if err != nil {
+		fatal("Invalid OpenAPI document", err)
+	}
 
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("POST /users", leaderboardHandler.CreateUser)
-	mux.HandleFunc("PUT /users/rating", leaderboardHandler.UpdateRating)
-	mux.HandleFunc("GET /leaderboard", leaderboardHandler.GetLeaderboard)
-	mux.HandleFunc("GET /users/rank", leaderboardHandler.GetUserWithRank)
+	if cfg.Features.Docs {
+		mux.HandleFunc("GET /openapi.json", spec.ServeSpec)
+		mux.HandleFunc("GET /docs", spec.ServeDocs)
+	}
+	if cfg.Features.Metrics {
+		mux.Handle("GET /metrics", metrics.Handler())
+	}
+	mux.HandleFunc("GET /livez", healthHandler.Livez)
+	mux.HandleFunc("GET /readyz", healthHandler.Readyz)
+	mux.HandleFunc("GET /healthz", healthHandler.Healthz)
+
+	mux.HandleFunc("POST /v1/users", leaderboardHandler.CreateUserV1)
+	mux.HandleFunc("GET /v1/users", leaderboardHandler.SearchUsersV1)
+	mux.HandleFunc("GET /v1/users/{id}", leaderboardHandler.GetUserV1)
+	mux.HandleFunc("PUT /v1/users/{id}/rating", leaderboardHandler.UpdateRatingV1)
+	mux.HandleFunc("GET /me", leaderboardHandler.GetMe)
+	mux.HandleFunc("GET /v1/leaderboard", leaderboardHandler.GetLeaderboardV1)
+	mux.HandleFunc("GET /v1/leaderboard/changes", leaderboardHandler.GetLeaderboardChangesV1)
+	mux.HandleFunc("GET /v1/leaderboard/wait", leaderboardHandler.WaitForLeaderboardV1)
 
-	// Simulation routes
-	// mux.HandleFunc("POST /simulation/start", leaderboardHandler.StartSimulation)
-	// mux.HandleFunc("POST /simulation/stop", leaderboardHandler.StopSimulation)
-	// mux.HandleFunc("GET /simulation/status", leaderboardHandler.GetSimulationStatus)
+	// Legacy routes, kept for existing clients
+	if cfg.Features.LegacyRoutes {
+		mux.HandleFunc("POST /users", handlers.Deprecated("/v1/users", leaderboardHandler.CreateUser))
+		mux.HandleFunc("PUT /users/rating", handlers.Deprecated("/v1/users/{id}/rating", leaderboardHandler.UpdateRating))
+		mux.HandleFunc("GET /leaderboard", handlers.Deprecated("/v1/leaderboard", leaderboardHandler.GetLeaderboard))
+		mux.HandleFunc("GET /leaderboard/changes", handlers.Deprecated("/v1/leaderboard/changes", leaderboardHandler.GetLeaderboardChanges))
+		mux.HandleFunc("GET /leaderboard/wait", handlers.Deprecated("/v1/leaderboard/wait", leaderboardHandler.WaitForLeaderboard))
+		mux.HandleFunc("GET /users/rank", handlers.Deprecated("/v1/users", leaderboardHandler.GetUserWithRank))
+	}
 
-	log.Println("Server started at :" + strconv.Itoa(cfg.SrvPort))
+	// Every admin route requires an admin key, and every admin action is
+	// recorded in the audit log.
+	admin := newRouteGroup(mux)
+	admin.HandleFunc("POST /admin/api-keys", adminHandler.IssueAPIKey)
+	admin.HandleFunc("GET /admin/api-keys", adminHandler.ListAPIKeys)
+	admin.HandleFunc("DELETE /admin/api-keys/{id}", adminHandler.RevokeAPIKey)
+	admin.HandleFunc("GET /admin/config", configHandler.GetConfig)
+	admin.HandleFunc("POST /admin/config/reload", configHandler.ReloadConfig)
+	admin.HandleFunc("GET /admin/simulation", operationsHandler.GetSimulation)
+	admin.HandleFunc("POST /admin/simulation/start", operationsHandler.StartSimulation)
+	admin.HandleFunc("POST /admin/simulation/stop", operationsHandler.StopSimulation)
+	admin.HandleFunc("PUT /admin/simulation/config", operationsHandler.SetSimulationConfig)
+	admin.HandleFunc("PATCH /admin/simulation/config", operationsHandler.UpdateSimulationConfig)
+	admin.HandleFunc("POST /admin/redis/resync", operationsHandler.ResyncRedis)
+	admin.HandleFunc("POST /admin/reconcile", operationsHandler.Reconcile)
+	admin.HandleFunc("GET /admin/stats", operationsHandler.GetStats)
+	admin.HandleFunc("GET /admin/maintenance", operationsHandler.GetMaintenance)
+	admin.HandleFunc("PUT /admin/maintenance", operationsHandler.SetMaintenance)
+	admin.HandleFunc("GET /admin/audit-log", operationsHandler.ListAuditLog)
 
-	handler := enableCORS(mux)
+	authorizer := middleware.NewAuthorizer(apiKeyService, tokenVerifier, mux)
+	authorizer.Require(auth.RoleScoreWriter,
+		"POST /v1/users",
+		"PUT /v1/users/{id}/rating",
+		"POST /users",
+		"PUT /users/rating",
+	)
+	authorizer.Require(auth.RoleReader, "GET /me")
+	authorizer.AllowPlayers(
+		"GET /me",
+		"PUT /v1/users/{id}/rating",
+		"PUT /users/rating",
+	)
+	authorizer.Require(auth.RoleAdmin, admin.patterns...)
+	if cfg.AuthRequireReadKey {
+		authorizer.Require(auth.RoleReader,
+			"GET /v1/users",
+			"GET /v1/users/{id}",
+			"GET /v1/leaderboard",
+			"GET /v1/leaderboard/changes",
+			"GET /v1/leaderboard/wait",
+			"GET /leaderboard",
+			"GET /leaderboard/changes",
+			"GET /leaderboard/wait",
+			"GET /users/rank",
+		)
+	}
+
+	buckets := newRateLimiter(rdb)
+	rateLimiter := middleware.NewRateLimiter(buckets, mux, cfg.RateLimitTrustProxy)
+	limits := rateLimits(cfg)
+	rateLimiter.Limit("reads", limits["reads"],
+		"GET /me",
+		"GET /v1/users/{id}",
+		"GET /v1/leaderboard",
+		"GET /v1/leaderboard/changes",
+		"GET /v1/leaderboard/wait",
+		"GET /leaderboard",
+		"GET /leaderboard/changes",
+		"GET /leaderboard/wait",
+	)
+	rateLimiter.Limit("writes", limits["writes"],
+		"POST /v1/users",
+		"PUT /v1/users/{id}/rating",
+		"POST /users",
+		"PUT /users/rating",
+	)
+	rateLimiter.Limit("search", limits["search"],
+		"GET /v1/users",
+		"GET /users/rank",
+	)
+
+	// Credentials are checked against Postgres, so limit attempts per IP
+	// address before they are.
+	authLimiter := middleware.NewRateLimiter(buckets, mux, cfg.RateLimitTrustProxy)
+	authLimiter.LimitCredentials("auth", limits["auth"])
+
+	idempotent := middleware.NewIdempotency(newIdempotencyStore(cfg, db, rdb), mux, cfg.IdempotencyTTL)
+	idempotent.Apply(
+		"POST /v1/users",
+		"PUT /v1/users/{id}/rating",
+		"POST /users",
+		"PUT /users/rating",
+	)
+
+	audit := middleware.NewAudit(auditService, mux)
+	audit.Apply(admin.actions()...)
+
+	// Maintenance mode refuses score writes; reads and admin routes keep
+	// working.
+	maintenanceMode := middleware.NewMaintenanceMode(maintenance, mux)
+	maintenanceMode.Apply(
+		"POST /v1/users",
+		"PUT /v1/users/{id}/rating",
+		"POST /users",
+		"PUT /users/rating",
+	)
+
+	// The scenario set through the admin API is kept across reloads that
+	// don't change the simulation settings. Reloads run one at a time.
+	loadedScenario := simulationConfig(cfg)
+	reloader.OnReload(func(cfg *config.Config) {
+		logging.SetLevels(logLevels(cfg))
+		postgresRepo.SetTimeouts(queryTimeouts(cfg))
+		apiKeyRepo.SetTimeouts(queryTimeouts(cfg))
+		auditRepo.SetTimeouts(queryTimeouts(cfg))
+		if scenario := simulationConfig(cfg); scenario != loadedScenario {
+			loadedScenario = scenario
+			simulationService.SetConfig(scenario)
+		}
+		rateLimiter.SetLimits(rateLimits(cfg))
+		rateLimiter.SetTrustProxy(cfg.RateLimitTrustProxy)
+		authLimiter.SetLimits(rateLimits(cfg))
+		authLimiter.SetTrustProxy(cfg.RateLimitTrustProxy)
+		idempotent.SetTTL(cfg.IdempotencyTTL)
+		if cache != nil {
+			cache.SetMaxStale(cfg.LeaderboardCacheMaxStale)
+		}
+	})
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	app.Add(lifecycle.Component{
+		Name:  "config watcher",
+		Start: func() error { go reloader.Watch(watchCtx); return nil },
+		Stop: func(context.Context) error {
+			stopWatching()
+			return nil
+		},
+	})
+
+	if cfg.GRPCPort > 0 {
+		grpcAuthorizer := grpcserver.NewAuthorizer(apiKeyService, tokenVerifier)
+		grpcAuthorizer.Require(auth.RoleScoreWriter,
+			leaderboardv1.LeaderboardService_CreateUser_FullMethodName,
+			leaderboardv1.LeaderboardService_UpdateRating_FullMethodName,
+			leaderboardv1.LeaderboardService_BatchUpdateRatings_FullMethodName,
+		)
+		grpcAuthorizer.AllowPlayers(leaderboardv1.LeaderboardService_UpdateRating_FullMethodName)
+		if cfg.AuthRequireReadKey {
+			grpcAuthorizer.Require(auth.RoleReader,
+				leaderboardv1.LeaderboardService_GetLeaderboard_FullMethodName,
+				leaderboardv1.LeaderboardService_SearchUsers_FullMethodName,
+				leaderboardv1.LeaderboardService_GetUserRank_FullMethodName,
+				leaderboardv1.LeaderboardService_WatchRatingChanges_FullMethodName,
+			)
+		}
+
+		grpcMaintenance := grpcserver.NewMaintenanceMode(maintenance)
+		grpcMaintenance.Apply(
+			leaderboardv1.LeaderboardService_CreateUser_FullMethodName,
+			leaderboardv1.LeaderboardService_UpdateRating_FullMethodName,
+			leaderboardv1.LeaderboardService_BatchUpdateRatings_FullMethodName,
+		)
+
+		// gRPC calls share the HTTP buckets, so a client has one budget
+		// across both APIs.
+		grpcRateLimiter := grpcserver.NewRateLimiter(buckets, cfg.RateLimitTrustProxy)
+		grpcRateLimiter.Limit("reads", limits["reads"],
+			leaderboardv1.LeaderboardService_GetLeaderboard_FullMethodName,
+			leaderboardv1.LeaderboardService_GetUserRank_FullMethodName,
+			leaderboardv1.LeaderboardService_WatchRatingChanges_FullMethodName,
+		)
+		grpcRateLimiter.Limit("writes", limits["writes"],
+			leaderboardv1.LeaderboardService_CreateUser_FullMethodName,
+			leaderboardv1.LeaderboardService_UpdateRating_FullMethodName,
+			leaderboardv1.LeaderboardService_BatchUpdateRatings_FullMethodName,
+		)
+		grpcRateLimiter.Limit("search", limits["search"],
+			leaderboardv1.LeaderboardService_SearchUsers_FullMethodName,
+		)
+		grpcAuthLimiter := grpcserver.NewRateLimiter(buckets, cfg.RateLimitTrustProxy)
+		grpcAuthLimiter.LimitCredentials("auth", limits["auth"])
+		reloader.OnReload(func(cfg *config.Config) {
+			grpcRateLimiter.SetLimits(rateLimits(cfg))
+			grpcRateLimiter.SetTrustProxy(cfg.RateLimitTrustProxy)
+			grpcAuthLimiter.SetLimits(rateLimits(cfg))
+			grpcAuthLimiter.SetTrustProxy(cfg.RateLimitTrustProxy)
+		})
+
+		leaderboardServer := grpcserver.NewLeaderboardServer(leaderboardService, bus)
+		grpcServer := newGRPCServer(leaderboardServer, grpcAuthorizer, grpcAuthLimiter, grpcRateLimiter, grpcMaintenance, cfg.Features.GRPCReflection)
+		app.Add(lifecycle.Component{
+			Name: "gRPC server",
+			Start: func() error {
+				lis, err := net.Listen("tcp", ":"+strconv.Itoa(cfg.GRPCPort))
+				if err != nil {
+					return err
+				}
+				slog.Info("gRPC server started", "addr", lis.Addr().String())
+				go func() {
+					if err := grpcServer.Serve(lis); err != nil {
+						app.Fail("gRPC server", err)
+					}
+				}()
+				return nil
+			},
+			Stop: func(ctx context.Context) error {
+				leaderboardServer.Drain()
+				return stopGRPC(ctx, grpcServer)
+			},
+		})
+	}
+
+	var routes http.Handler = mux
+	if cfg.Features.RequestValidation {
+		routes = spec.ValidateRequests(mux)
+	}
+	handler := enableCORS(authLimiter.Middleware(authorizer.Middleware(audit.Middleware(maintenanceMode.Middleware(rateLimiter.Middleware(idempotent.Middleware(routes)))))))
+	if cfg.Features.Metrics {
+		handler = middleware.NewMetrics(mux).Middleware(handler)
+	}
+	handler = middleware.NewAccessLog(mux).Middleware(handler)
+	handler = middleware.RequestID(handler)
+	handler = middleware.NewTracing(mux).Middleware(handler)
+
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.HTTP.Port),
+		Handler:           handler,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+		MaxHeaderBytes:    cfg.HTTP.MaxHeaderBytes,
+	}
+	// Long polls would otherwise keep their connections busy for up to a
+	// minute after shutdown starts.
+	srv.RegisterOnShutdown(topWatcher.Drain)
+	app.Add(lifecycle.Component{
+		Name: "HTTP server",
+		Start: func() error {
+			lis, err := net.Listen("tcp", srv.Addr)
+			if err != nil {
+				return err
+			}
+			slog.Info("HTTP server started", "addr", lis.Addr().String())
+			go func() {
+				if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+					app.Fail("HTTP server", err)
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	})
+	// Stopped first, so load balancers stop sending requests before the
+	// servers stop taking them.
+	app.Add(lifecycle.Component{Name: "readiness", Stop: func(context.Context) error {
+		checker.ShutDown()
+		return nil
+	}})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	// A second signal kills the server without waiting for the shutdown.
+	context.AfterFunc(ctx, stop)
+
+	if err := app.Run(ctx, cfg.ShutdownTimeout); err != nil {
+		fatal("Server stopped with errors", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// exit prints err and exits, for errors found before logging is set up.
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// newGRPCServer returns the gRPC server, with its interceptors in the order
+// of the HTTP middleware: attempts to authenticate are limited before the
+// credentials are checked, and calls are limited by caller after.
+func newGRPCServer(leaderboardServer *grpcserver.LeaderboardServer, authorizer *grpcserver.Authorizer, authLimiter, rateLimiter *grpcserver.RateLimiter, maintenance *grpcserver.MaintenanceMode, withReflection bool) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(authLimiter.UnaryInterceptor, authorizer.UnaryInterceptor, rateLimiter.UnaryInterceptor, maintenance.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(authLimiter.StreamInterceptor, authorizer.StreamInterceptor, rateLimiter.StreamInterceptor),
+	)
+	leaderboardv1.RegisterLeaderboardServiceServer(srv, leaderboardServer)
+	if withReflection {
+		reflection.Register(srv)
+	}
+	return srv
+}
+
+// routeGroup registers routes on a mux and remembers their patterns, so
+// middleware can be applied to the whole group.
+type routeGroup struct {
+	mux      *http.ServeMux
+	patterns []string
+}
+
+func newRouteGroup(mux *http.ServeMux) *routeGroup {
+	return &routeGroup{mux: mux}
+}
+
+func (g *routeGroup) HandleFunc(pattern string, handler http.HandlerFunc) {
+	g.mux.HandleFunc(pattern, handler)
+	g.patterns = append(g.patterns, pattern)
+}
+
+// actions returns the patterns of the routes that change something, that
+// is all but the GET ones.
+func (g *routeGroup) actions() []string {
+	var actions []string
+	for _, pattern := range g.patterns {
+		if !strings.HasPrefix(pattern, http.MethodGet+" ") {
+			actions = append(actions, pattern)
+		}
+	}
+	return actions
+}
+
+// stopGRPC lets in-flight calls finish, then cancels those still running
+// when ctx is done.
+func stopGRPC(ctx context.Context, srv *grpc.Server) error {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		srv.Stop()
+		return ctx.Err()
+	}
+}
+
+func logLevels(cfg *config.Config) logging.Levels {
+	return logging.Levels{Default: cfg.LogLevel, Components: cfg.LogLevels}
+}
+
+func simulationConfig(cfg *config.Config) services.SimulationConfig {
+	return services.SimulationConfig{
+		Interval:          cfg.Simulation.Interval,
+		UpdatesPerTick:    cfg.Simulation.UpdatesPerTick,
+		Population:        cfg.Simulation.Population,
+		PopulationRefresh: cfg.Simulation.PopulationRefresh,
+		Distribution:      services.RatingDistribution(cfg.Simulation.Distribution),
+		MinRating:         cfg.Simulation.MinRating,
+		MaxRating:         cfg.Simulation.MaxRating,
+		RatingMean:        cfg.Simulation.RatingMean,
+		RatingStdDev:      cfg.Simulation.RatingStdDev,
+		ZipfS:             cfg.Simulation.ZipfS,
+		Seed:              int64(cfg.Simulation.Seed),
+		BurstEvery:        cfg.Simulation.BurstEvery,
+		BurstDuration:     cfg.Simulation.BurstDuration,
+		BurstFactor:       cfg.Simulation.BurstFactor,
+	}
+}
+
+func queryTimeouts(cfg *config.Config) repository.Timeouts {
+	return repository.Timeouts{
+		Read:   cfg.Queries.ReadTimeout,
+		Write:  cfg.Queries.WriteTimeout,
+		Search: cfg.Queries.SearchTimeout,
+		Sync:   cfg.Queries.SyncTimeout,
+	}
+}
+
+// rateLimits returns the limits of the rate limited route groups.
+func rateLimits(cfg *config.Config) map[string]ratelimit.Limit {
+	return map[string]ratelimit.Limit{
+		"reads":  cfg.RateLimitReads,
+		"writes": cfg.RateLimitWrites,
+		"search": cfg.RateLimitSearch,
+		"auth":   cfg.RateLimitAuth,
+	}
+}
+
+// newTokenVerifier returns the verifier of player tokens, or nil when no
+// keys are configured and player tokens are disabled.
+func newTokenVerifier(cfg *config.Config) *auth.TokenVerifier {
+	tokenConfig := auth.TokenConfig{
+		HMACSecret:    cfg.JWTHMACSecret,
+		PublicKeyFile: cfg.JWTPublicKeyFile,
+		JWKSFile:      cfg.JWTJWKSFile,
+		Issuer:        cfg.JWTIssuer,
+		Audience:      cfg.JWTAudience,
+	}
+	if !tokenConfig.Enabled() {
+		return nil
+	}
+
+	verifier, err := auth.NewTokenVerifier(tokenConfig)
+	if err != nil {
+		fatal("Invalid player token configuration", err)
+	}
+	return verifier
+}
+
+// newHealthChecker checks what the server needs to serve: Postgres with
+// every migration applied and, when Redis is in use, the initial sync of
+// the Redis leaderboard. Redis itself only degrades the server when it is
+// unreachable, unless HEALTH_REQUIRE_REDIS is set, since reads fall back
+// to Postgres.
+func newHealthChecker(cfg *config.Config, db *gorm.DB, sqlDB *sql.DB, rdb *redis.Client, repo *repository.PostgresUserRepository) *health.Checker {
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.Add(health.Check{Name: "postgres", Critical: true, Run: sqlDB.PingContext})
+	checker.Add(health.Check{Name: "migrations", Critical: true, Run: func(ctx context.Context) error {
+		pending, err := database.PendingMigrations(ctx, db)
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("pending migrations: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	}})
+	checker.Add(health.Check{Name: "redis", Critical: cfg.Health.RequireRedis, Run: func(ctx context.Context) error {
+		if rdb == nil {
+			return errors.New("not connected: Redis was unreachable at startup, serving from Postgres only")
+		}
+		return rdb.Ping(ctx).Err()
+	}})
+	checker.Add(health.Check{Name: "redis_sync", Critical: true, Run: func(context.Context) error {
+		return repo.InitialSync()
+	}})
+	return checker
+}
+
+// newRateLimiter shares rate limits across instances through Redis, or
+// enforces them per instance when Redis is unavailable.
+func newRateLimiter(rdb *redis.Client) ratelimit.Limiter {
+	if rdb == nil {
+		return ratelimit.NewMemoryLimiter()
+	}
+	return ratelimit.NewRedisLimiter(rdb)
+}
+
+// newIdempotencyStore keeps idempotent responses in the configured store,
+// or in Postgres when Redis is unavailable.
+func newIdempotencyStore(cfg *config.Config, db *gorm.DB, rdb *redis.Client) idempotency.Store {
+	if cfg.IdempotencyStore == "redis" && rdb != nil {
+		return idempotency.NewRedisStore(rdb)
+	}
+	return idempotency.NewPostgresStore(db)
+}
+
+// newEventBus fans leaderboard events out across instances through Redis,
+// or only within this process when Redis is unavailable.
+func newEventBus(rdb *redis.Client) events.Bus {
+	if rdb == nil {
+		return events.NewInMemoryBus()
+	}
 
-	if err := http.ListenAndServe(":"+strconv.Itoa(cfg.SrvPort), handler); err != nil {
-		log.Fatal(err)
+	bus, err := events.NewRedisBus(rdb, events.DefaultChannel)
+	if err != nil {
+		slog.Warn("Redis event bus unavailable, falling back to in-process events", "error", err)
+		return events.NewInMemoryBus()
 	}
+	return bus
 }
 
 func enableCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-None-Match, If-Match, X-API-Key, Idempotency-Key, X-Request-Id, traceparent, tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Location, Deprecation, Link, RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, Idempotent-Replayed, X-Request-Id, X-Trace-Id")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
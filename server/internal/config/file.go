@@ -0,0 +1,94 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads a YAML or TOML config file, chosen by its extension, into
+// settings named like their environment variables. Nested sections are
+// joined with underscores, so these are all HTTP_READ_TIMEOUT:
+//
+//	http_read_timeout: 5s
+//
+//	http:
+//	  read_timeout: 5s
+//
+//	[http]
+//	read_timeout = "5s"
+//
+// Lists may be written as arrays or as comma separated strings.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &doc)
+	case ".toml":
+		err = toml.Unmarshal(data, &doc)
+	default:
+		return nil, fmt.Errorf("%s: unsupported format %q, expected .yaml, .yml or .toml", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	if err := flatten(settings, "", doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return settings, nil
+}
+
+func flatten(settings map[string]string, prefix string, doc map[string]any) error {
+	for name, value := range doc {
+		key := strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			if err := flatten(settings, key, v); err != nil {
+				return err
+			}
+			continue
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			value = strings.Join(items, ",")
+		case nil:
+			value = ""
+		}
+
+		if _, dup := settings[key]; dup {
+			return fmt.Errorf("%s is set more than once", key)
+		}
+		settings[key] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+// unknownKeys returns the settings of a file that don't exist, sorted.
+func (l *loader) unknownKeys() []string {
+	var unknown []string
+	for key := range l.file {
+		if !l.known(key) {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
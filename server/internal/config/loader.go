@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+	"leaderboard/internal/ratelimit"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of a setting, in increasing precedence.
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// setting is a resolved setting, kept to print the configuration.
+type setting struct {
+	key    string
+	value  string
+	source string
+	// redact hides secrets in the value when the configuration is printed.
+	redact func(string) string
+}
+
+// loader resolves settings from, in increasing precedence, their defaults,
+// the config file, the environment and command line flags. Settings are
+// named after their environment variable; the same name identifies them in
+// the file and, lowercased with hyphens, as flags.
+//
+// Invalid values are collected rather than reported one at a time, so a bad
+// configuration can be fixed in one go.
+type loader struct {
+	file     map[string]string
+	flags    map[string]string
+	settings []setting
+	problems []string
+}
+
+func (l *loader) lookup(key string) (string, string, bool) {
+	if value, ok := l.flags[key]; ok {
+		return value, sourceFlag, true
+	}
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value, sourceEnv, true
+	}
+	if value, ok := l.file[key]; ok {
+		return value, sourceFile, true
+	}
+	return "", sourceDefault, false
+}
+
+func (l *loader) record(key, value, source string, redact func(string) string) {
+	l.settings = append(l.settings, setting{key: key, value: value, source: source, redact: redact})
+}
+
+func (l *loader) invalid(format string, args ...any) {
+	l.problems = append(l.problems, fmt.Sprintf(format, args...))
+}
+
+// known reports whether a setting of that name was read.
+func (l *loader) known(key string) bool {
+	return slices.ContainsFunc(l.settings, func(s setting) bool { return s.key == key })
+}
+
+// read returns the raw value of a setting, or def when it is unset, and
+// records it.
+func (l *loader) read(key, def string, redact func(string) string) (string, bool) {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value = def
+	}
+	l.record(key, value, source, redact)
+	return value, ok
+}
+
+func (l *loader) getString(key, def string) string {
+	value, _ := l.read(key, def, nil)
+	return value
+}
+
+// getRedacted reads a setting holding a secret, which redact hides when
+// the configuration is printed.
+func (l *loader) getRedacted(key string, redact func(string) string) string {
+	value, _ := l.read(key, "", redact)
+	return value
+}
+
+// getOneOf reads a setting that must be one of allowed.
+func (l *loader) getOneOf(key, def string, allowed ...string) string {
+	value, _ := l.read(key, def, nil)
+	if !slices.Contains(allowed, value) {
+		l.invalid("%s must be one of %v, got %q", key, allowed, value)
+		return def
+	}
+	return value
+}
+
+func (l *loader) getInt(key string, def int) int {
+	value, ok := l.read(key, strconv.Itoa(def), nil)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid("%s must be an integer, got %q", key, value)
+		return def
+	}
+	return n
+}
+
+// getBool reads a boolean such as "true" or "0".
+func (l *loader) getBool(key string, def bool) bool {
+	value, ok := l.read(key, strconv.FormatBool(def), nil)
+	if !ok {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid("%s must be a boolean, got %q", key, value)
+		return def
+	}
+	return b
+}
+
+// getDuration reads a duration such as "500ms" or "5s".
+func (l *loader) getDuration(key string, def time.Duration) time.Duration {
+	value, ok := l.read(key, def.String(), nil)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid("%s must be a duration such as 5s, got %q", key, value)
+		return def
+	}
+	return d
+}
+
+// getFloat reads a number such as "2.5".
+func (l *loader) getFloat(key string, def float64) float64 {
+	value, ok := l.read(key, strconv.FormatFloat(def, 'g', -1, 64), nil)
+	if !ok {
+		return def
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid("%s must be a number, got %q", key, value)
+		return def
+	}
+	return f
+}
+
+// getList reads a comma separated list. Unlike other settings, an empty
+// environment variable or flag sets an empty list.
+func (l *loader) getList(key string, def []string) []string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		if env, set := os.LookupEnv(key); set && env == "" {
+			value, source, ok = "", sourceEnv, true
+		}
+	}
+	if !ok {
+		l.record(key, strings.Join(def, ","), source, nil)
+		return def
+	}
+	l.record(key, value, source, nil)
+
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getLimit reads a rate limit from <prefix>_RATE (requests per second) and
+// <prefix>_BURST.
+func (l *loader) getLimit(prefix string, def ratelimit.Limit) ratelimit.Limit {
+	return ratelimit.Limit{
+		Rate:  l.getFloat(prefix+"_RATE", def.Rate),
+		Burst: l.getInt(prefix+"_BURST", def.Burst),
+	}
+}
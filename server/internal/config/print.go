@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Setting is a setting as shown to operators, with secrets redacted.
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+	// Reloadable settings take effect on reload, the others on restart.
+	Reloadable bool `json:"reloadable"`
+}
+
+// Settings returns every setting in the order they are documented.
+func (c *Config) Settings() []Setting {
+	settings := make([]Setting, len(c.settings))
+	for i, s := range c.settings {
+		value := s.value
+		if s.redact != nil {
+			value = s.redact(value)
+		}
+		settings[i] = Setting{Key: s.key, Value: value, Source: s.source, Reloadable: reloadable[s.key]}
+	}
+	return settings
+}
+
+// Print writes the configuration as a YAML config file, noting where each
+// setting came from. Secrets are redacted.
+func (c *Config) Print(w io.Writer) error {
+	for _, s := range c.Settings() {
+		_, err := fmt.Fprintf(w, "%s: %s # %s\n", strings.ToLower(s.Key), strconv.Quote(s.Value), s.Source)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func redactSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+// redactURL hides the password of a URL, or the whole URL if it can't be
+// parsed.
+func redactURL(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return redactSecret(value)
+	}
+	return u.Redacted()
+}
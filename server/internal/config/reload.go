@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reloadable lists the settings applied to a running server on reload.
+// The others, such as ports, pools and feature toggles, shape how the
+// server is put together and only take effect on restart.
+var reloadable = map[string]bool{
+	"QUERY_READ_TIMEOUT":            true,
+	"QUERY_WRITE_TIMEOUT":           true,
+	"QUERY_SEARCH_TIMEOUT":          true,
+	"QUERY_SYNC_TIMEOUT":            true,
+	"SIMULATION_INTERVAL":           true,
+	"SIMULATION_UPDATES_PER_TICK":   true,
+	"SIMULATION_POPULATION":         true,
+	"SIMULATION_POPULATION_REFRESH": true,
+	"SIMULATION_DISTRIBUTION":       true,
+	"SIMULATION_MIN_RATING":         true,
+	"SIMULATION_MAX_RATING":         true,
+	"SIMULATION_RATING_MEAN":        true,
+	"SIMULATION_RATING_STDDEV":      true,
+	"SIMULATION_ZIPF_S":             true,
+	"SIMULATION_SEED":               true,
+	"SIMULATION_BURST_EVERY":        true,
+	"SIMULATION_BURST_DURATION":     true,
+	"SIMULATION_BURST_FACTOR":       true,
+	"RATE_LIMIT_READ_RATE":          true,
+	"RATE_LIMIT_READ_BURST":         true,
+	"RATE_LIMIT_WRITE_RATE":         true,
+	"RATE_LIMIT_WRITE_BURST":        true,
+	"RATE_LIMIT_SEARCH_RATE":        true,
+	"RATE_LIMIT_SEARCH_BURST":       true,
+	"RATE_LIMIT_AUTH_RATE":          true,
+	"RATE_LIMIT_AUTH_BURST":         true,
+	"RATE_LIMIT_TRUST_PROXY":        true,
+	"LEADERBOARD_CACHE_MAX_STALE":   true,
+	"IDEMPOTENCY_TTL":               true,
+	"LOG_LEVEL":                     true,
+	"LOG_LEVELS":                    true,
+}
+
+// withReloadable returns a copy of c with the reloadable settings of next.
+func (c *Config) withReloadable(next *Config) *Config {
+	cfg := *c
+	cfg.Queries = next.Queries
+
+	autostart := c.Simulation.Autostart
+	cfg.Simulation = next.Simulation
+	cfg.Simulation.Autostart = autostart
+	cfg.RateLimitReads = next.RateLimitReads
+	cfg.RateLimitWrites = next.RateLimitWrites
+	cfg.RateLimitSearch = next.RateLimitSearch
+	cfg.RateLimitAuth = next.RateLimitAuth
+	cfg.RateLimitTrustProxy = next.RateLimitTrustProxy
+	cfg.LeaderboardCacheMaxStale = next.LeaderboardCacheMaxStale
+	cfg.IdempotencyTTL = next.IdempotencyTTL
+	cfg.LogLevel = next.LogLevel
+	cfg.LogLevels = next.LogLevels
+
+	cfg.settings = make([]setting, len(c.settings))
+	for i, s := range c.settings {
+		if reloadable[s.key] {
+			s = next.setting(s.key)
+		}
+		cfg.settings[i] = s
+	}
+	return &cfg
+}
+
+func (c *Config) setting(key string) setting {
+	for _, s := range c.settings {
+		if s.key == key {
+			return s
+		}
+	}
+	return setting{key: key}
+}
+
+// changes returns the settings whose values differ in next.
+func (c *Config) changes(next *Config) []string {
+	var changed []string
+	for _, s := range c.settings {
+		if next.setting(s.key).value != s.value {
+			changed = append(changed, s.key)
+		}
+	}
+	return changed
+}
+
+// Active is the configuration a server is running with.
+type Active struct {
+	Config *Config
+	// Version starts at 1 and counts the reloads that changed something.
+	Version  int
+	LoadedAt time.Time
+}
+
+// Reloader keeps the configuration of a running server and reloads it from
+// the same sources it was first loaded from. Reloads that fail validation
+// are rejected as a whole. Otherwise the reloadable settings are passed to
+// every function registered with OnReload, in one call each, so components
+// switch to a consistent set of values at once.
+type Reloader struct {
+	args []string
+
+	mu       sync.Mutex
+	active   Active
+	onReload []func(*Config)
+}
+
+// NewReloader starts from cfg, loaded from the command line arguments args.
+func NewReloader(cfg *Config, args []string) *Reloader {
+	return &Reloader{args: args, active: Active{Config: cfg, Version: 1, LoadedAt: time.Now()}}
+}
+
+// OnReload registers fn to be called with the new configuration after each
+// reload that changed a reloadable setting.
+func (r *Reloader) OnReload(fn func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReload = append(r.onReload, fn)
+}
+
+// Active returns the configuration in use.
+func (r *Reloader) Active() Active {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active
+}
+
+// Reload loads the configuration again and applies its reloadable
+// settings. Changed settings that need a restart are logged and ignored.
+func (r *Reloader) Reload() (Active, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next, err := Load(r.args)
+	if err != nil {
+		return r.active, err
+	}
+
+	var applied, ignored []string
+	for _, key := range r.active.Config.changes(next) {
+		if reloadable[key] {
+			applied = append(applied, key)
+		} else {
+			ignored = append(ignored, key)
+		}
+	}
+	if len(ignored) > 0 {
+		logger.Warn("Changed settings take effect on restart", "settings", ignored)
+	}
+	if len(applied) == 0 {
+		return r.active, nil
+	}
+
+	cfg := r.active.Config.withReloadable(next)
+	r.active = Active{Config: cfg, Version: r.active.Version + 1, LoadedAt: time.Now()}
+	for _, fn := range r.onReload {
+		fn(cfg)
+	}
+
+	logger.Info("Configuration reloaded", "version", r.active.Version, "settings", applied)
+	return r.active, nil
+}
+
+// Watch reloads the configuration on SIGHUP and, when it was read from a
+// file and WatchInterval is set, whenever the file changes, until ctx is
+// done.
+func (r *Reloader) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	cfg := r.Active().Config
+	var poll <-chan time.Time
+	if cfg.file != "" && cfg.WatchInterval > 0 {
+		ticker := time.NewTicker(cfg.WatchInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	lastModified := modTime(cfg.file)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reloadAndLog("SIGHUP")
+		case <-poll:
+			if t := modTime(cfg.file); !t.Equal(lastModified) {
+				lastModified = t
+				r.reloadAndLog(cfg.file + " changed")
+			}
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog(reason string) {
+	if _, err := r.Reload(); err != nil {
+		logger.Error("Configuration not reloaded", "reason", reason, "error", err)
+	}
+}
+
+// modTime returns when a file was last modified, or the zero time if it
+// can't be read.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockID identifies the advisory lock that keeps several server
+// instances from applying the same migration at once.
+const migrationLockID = 7245031
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+	// run is the code of migrations written in Go rather than SQL.
+	run func(tx *gorm.DB) error
+}
+
+// goMigrations are the migrations that need code of the server, such as
+// the username normalizer, numbered along with those in migrations/.
+var goMigrations = []migration{
+	{version: 6, name: "backfill_username_normalized", run: backfillUsernameNormalized},
+}
+
+// Migrate applies every migration in migrations/ or goMigrations that has
+// not been applied yet, in order. Files are named <version>_<name>.sql and
+// each migration runs in its own transaction.
+func Migrate(db *gorm.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	err = db.Exec(`
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+	)`).Error
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+
+			var applied bool
+			if err := tx.Raw("SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)", m.version).Scan(&applied).Error; err != nil {
+				return err
+			}
+			if applied {
+				return nil
+			}
+
+			logger.Info("Applying migration", "version", m.version, "name", m.name)
+			if m.run != nil {
+				err = m.run(tx)
+			} else {
+				err = tx.Exec(m.sql).Error
+			}
+			if err != nil {
+				return err
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.version, m.name).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	return nil
+}
+
+// PendingMigrations returns the migrations that have not been applied to
+// db, as <version>_<name>. Migrations applied by a newer version of the
+// server are not reported.
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]string, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []int
+	if err := db.WithContext(ctx).Raw("SELECT version FROM schema_migrations").Scan(&applied).Error; err != nil {
+		return nil, err
+	}
+
+	var pending []string
+	for _, m := range migrations {
+		if !slices.Contains(applied, m.version) {
+			pending = append(pending, fmt.Sprintf("%04d_%s", m.version, m.name))
+		}
+	}
+	return pending, nil
+}
+
+func loadMigrations() ([]migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, e := range entries {
+		versionStr, name, ok := strings.Cut(strings.TrimSuffix(e.Name(), ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.sql", e.Name())
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version: %w", e.Name(), err)
+		}
+
+		sql, err := migrationFiles.ReadFile("migrations/" + e.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(sql)})
+	}
+
+	migrations = append(migrations, goMigrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	return migrations, nil
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"fmt"
+	"leaderboard/internal/username"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// backfillUsernameNormalized sets username_normalized to the canonical
+// form of every username and makes it unique. Usernames that are the same
+// once canonical, which could be registered before usernames were
+// normalized, can't all keep their name: the migration fails, naming them,
+// until all but one of each are renamed.
+func backfillUsernameNormalized(tx *gorm.DB) error {
+	var exists bool
+	if err := tx.Raw("SELECT to_regclass('users') IS NOT NULL").Scan(&exists).Error; err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	var users []struct {
+		ID                 int
+		Username           string
+		UsernameNormalized *string
+	}
+	if err := tx.Table("users").Select("id", "username", "username_normalized").Order("id").Scan(&users).Error; err != nil {
+		return err
+	}
+
+	owners := make(map[string]string, len(users))
+	canonical := make(map[int]string)
+	var clashes []string
+	for _, u := range users {
+		c := username.Canonical(u.Username)
+		if owner, ok := owners[c]; ok {
+			clashes = append(clashes, strconv.Quote(owner)+" and "+strconv.Quote(u.Username))
+			continue
+		}
+		owners[c] = u.Username
+		if u.UsernameNormalized == nil || *u.UsernameNormalized != c {
+			canonical[u.ID] = c
+		}
+	}
+	if len(clashes) > 0 {
+		return fmt.Errorf("usernames differing only in case or lookalike characters must be renamed first: %s", strings.Join(clashes, ", "))
+	}
+
+	// A user can take the old value of another, which the unique index
+	// would refuse while both are set, so the values change in two passes
+	// through placeholders. Placeholders have capitals, which case folded
+	// canonical forms never do.
+	for id := range canonical {
+		if err := tx.Exec("UPDATE users SET username_normalized = ? WHERE id = ?", "MIGRATING "+strconv.Itoa(id), id).Error; err != nil {
+			return err
+		}
+	}
+	for id, c := range canonical {
+		if err := tx.Exec("UPDATE users SET username_normalized = ? WHERE id = ?", c, id).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Exec(`
+	ALTER TABLE users ALTER COLUMN username_normalized SET NOT NULL;
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username_normalized ON users (username_normalized);
+	`).Error
+}
@@ -0,0 +1,3 @@
+-- The users table is created by the seed command, which includes this
+-- column; this adds it to databases seeded before it existed.
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,6 @@
+-- Usernames are unique ignoring case and lookalike characters. This adds
+-- the column of their canonical form; migration 6 fills it in for
+-- existing users, with the normalizer the server uses, and makes it
+-- unique. The users table is created by the seed command, so there may be
+-- nothing to migrate yet.
+ALTER TABLE IF EXISTS users ADD COLUMN IF NOT EXISTS username_normalized TEXT;
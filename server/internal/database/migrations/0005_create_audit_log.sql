@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS audit_log (
+	id BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+	actor TEXT NOT NULL,
+	key_id INT,
+	action TEXT NOT NULL,
+	status INT NOT NULL,
+	request_id TEXT NOT NULL,
+	details JSONB
+);
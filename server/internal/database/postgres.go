@@ -3,20 +3,39 @@ package database
 import (
 	"fmt"
 	"leaderboard/internal/config"
-	"log"
+	"leaderboard/internal/logging"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
+var logger = logging.Logger("database")
+
+// queryLogger logs failed and slow queries, without the values bound to
+// them.
+var queryLogger = gormlogger.NewSlogLogger(logger, gormlogger.Config{
+	SlowThreshold:             200 * time.Millisecond,
+	IgnoreRecordNotFoundError: true,
+	ParameterizedQueries:      true,
+	LogLevel:                  gormlogger.Warn,
+})
+
+// fatal logs err and exits: the server can't run without its connections.
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
 func New(cfg *config.Config) *gorm.DB {
 	// Parse the database URL to get the database name and base URL
 	u, err := url.Parse(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatal("invalid database url")
+		fatal("Invalid database URL", err)
 	}
 
 	dbName := strings.TrimPrefix(u.Path, "/")
@@ -26,13 +45,13 @@ func New(cfg *config.Config) *gorm.DB {
 	postgresURL := u.String()
 
 	// 1. Connect to 'postgres' database to ensure the target database exists
-	tempDb, err := gorm.Open(postgres.Open(postgresURL), &gorm.Config{})
+	tempDb, err := gorm.Open(postgres.Open(postgresURL), &gorm.Config{Logger: queryLogger})
 	if err != nil {
-		log.Fatalf("failed to connect to default postgres database: %v", err)
+		fatal("Failed to connect to default postgres database", err)
 	}
 
 	if err := createDatabaseIfNotExists(tempDb, dbName); err != nil {
-		log.Fatalf("failed to create database %s: %v", dbName, err)
+		fatal("Failed to create database "+dbName, err)
 	}
 
 	// Close temporary connection
@@ -40,19 +59,26 @@ func New(cfg *config.Config) *gorm.DB {
 	sqlTempDb.Close()
 
 	// 2. Connect to the actual target database
-	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+	// TranslateError turns driver errors such as unique violations into
+	// gorm errors the repositories can check for.
+	db, err := gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{TranslateError: true, Logger: queryLogger})
 	if err != nil {
-		log.Fatalf("failed to connect to target database %s: %v", dbName, err)
+		fatal("Failed to connect to target database "+dbName, err)
+	}
+
+	if err := useTracing(db); err != nil {
+		fatal("Failed to enable query tracing", err)
 	}
 
 	sqlDb, err := db.DB()
 	if err != nil {
-		log.Fatal("failed to get sqlDB")
+		fatal("Failed to get database handle", err)
 	}
 
-	sqlDb.SetMaxIdleConns(25)
-	sqlDb.SetMaxOpenConns(125)
-	sqlDb.SetConnMaxLifetime(10 * time.Minute)
+	sqlDb.SetMaxIdleConns(cfg.Postgres.MaxIdleConns)
+	sqlDb.SetMaxOpenConns(cfg.Postgres.MaxOpenConns)
+	sqlDb.SetConnMaxLifetime(cfg.Postgres.ConnMaxLifetime)
+	sqlDb.SetConnMaxIdleTime(cfg.Postgres.ConnMaxIdleTime)
 
 	return db
 }
@@ -66,7 +92,7 @@ func createDatabaseIfNotExists(db *gorm.DB, dbName string) error {
 	}
 
 	if !exists {
-		log.Printf("Creating database %s...", dbName)
+		logger.Info("Creating database", "database", dbName)
 		// Only create if not exists
 		// Note: CREATE DATABASE cannot be executed within a transaction block
 		return db.Exec(fmt.Sprintf("CREATE DATABASE %s;", dbName)).Error
@@ -2,30 +2,80 @@ package database
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"leaderboard/internal/config"
-	"log"
+	"leaderboard/internal/metrics"
+	"os"
+	"runtime"
 
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
+// redisLogger passes the messages of the Redis client, such as failed
+// dials, to the database logger.
+type redisLogger struct{}
+
+func (redisLogger) Printf(ctx context.Context, format string, v ...any) {
+	logger.WarnContext(ctx, fmt.Sprintf(format, v...))
+}
+
+func init() {
+	redis.SetLogger(redisLogger{})
+}
+
 func NewRedis(cfg *config.Config) *redis.Client {
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisURL,
-		Username: "default",
-		Password: cfg.RedisPassword,
-		DB:       0,
-	})
+	opts := &redis.Options{
+		Addr:     cfg.Redis.URL,
+		Username: cfg.Redis.Username,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+		PoolSize: cfg.Redis.PoolSize * runtime.GOMAXPROCS(0),
+	}
+	if cfg.Redis.TLS {
+		tlsConfig, err := redisTLSConfig(cfg.Redis)
+		if err != nil {
+			fatal("Invalid Redis TLS configuration", err)
+		}
+		opts.TLSConfig = tlsConfig
+	}
+	rdb := redis.NewClient(opts)
+	rdb.AddHook(metrics.RedisHook{})
+	// Commands and pipelines each get a span.
+	if err := redisotel.InstrumentTracing(rdb); err != nil {
+		fatal("Failed to enable Redis tracing", err)
+	}
 
 	// Check connection
 	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		log.Printf("⚠️ Redis not reachable at %s. Falling back to Postgres only mode.", cfg.RedisURL)
+		logger.Warn("Redis not reachable, falling back to Postgres only mode", "addr", cfg.Redis.URL, "error", err)
 		return nil
 	}
 
-	log.Println("✅ Successfully connected to Redis")
+	logger.Info("Connected to Redis", "addr", cfg.Redis.URL)
 	return rdb
 }
 
+func redisTLSConfig(cfg config.RedisConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: cfg.TLSServerName}
+	if cfg.TLSCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(cfg.TLSCAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s holds no PEM certificates", cfg.TLSCAFile)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
 // func ExampleClient_connect_basic() {
 // 	ctx := context.Background()
 
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type Type string
+
+const (
+	UserCreated   Type = "user.created"
+	RatingUpdated Type = "rating.updated"
+	// LeaderboardReset is published when the whole leaderboard was rebuilt
+	// and any derived state has to be reloaded. Subscribers that fell behind
+	// also get one in place of the events they missed.
+	LeaderboardReset Type = "leaderboard.reset"
+)
+
+// Event describes a single change to the leaderboard.
+type Event struct {
+	Type Type `json:"type"`
+	// Version is the leaderboard version the change brought it to. It is
+	// 0 on a reset published because a change couldn't be recorded or
+	// delivered to a subscriber, after which readers must start over
+	// without knowing the version.
+	Version   int64     `json:"version"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	OldRating int       `json:"old_rating"`
+	NewRating int       `json:"new_rating"`
+	At        time.Time `json:"at"`
+}
+
+// Bus delivers leaderboard events to every subscriber of this instance.
+// Implementations backed by a broker also deliver events published by
+// other instances.
+type Bus interface {
+	Publish(ctx context.Context, e Event) error
+	// Subscribe returns a channel of events and a function that must be
+	// called to release the subscription.
+	Subscribe() (<-chan Event, func())
+	Close() error
+}
+
+const subscriberBuffer = 256
+
+// hub fans events out to local subscribers. Slow subscribers never block
+// the publisher: when a subscriber's buffer is full the event is dropped
+// for that subscriber only, and it gets a LeaderboardReset instead as soon
+// as its buffer has room again.
+type hub struct {
+	mu     sync.RWMutex
+	subs   map[chan Event]*subscriber
+	closed bool
+}
+
+type subscriber struct {
+	ch chan Event
+	// lost is set while the subscriber owes a reset for dropped events.
+	lost atomic.Bool
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan Event]*subscriber)}
+}
+
+func (h *hub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	h.mu.Lock()
+	if h.closed {
+		h.mu.Unlock()
+		close(ch)
+		return ch, func() {}
+	}
+	h.subs[ch] = &subscriber{ch: ch}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if _, ok := h.subs[ch]; ok {
+				delete(h.subs, ch)
+				close(ch)
+			}
+		})
+	}
+}
+
+func (h *hub) broadcast(e Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, sub := range h.subs {
+		sub.send(e)
+	}
+}
+
+func (s *subscriber) send(e Event) {
+	if s.lost.Load() {
+		select {
+		case s.ch <- Event{Type: LeaderboardReset, At: e.At}:
+			s.lost.Store(false)
+		default:
+			return
+		}
+	}
+	select {
+	case s.ch <- e:
+	default:
+		s.lost.Store(true)
+	}
+}
+
+func (h *hub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
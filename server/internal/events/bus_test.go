@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func rated(userID int) Event {
+	return Event{Type: RatingUpdated, UserID: userID, Version: int64(userID)}
+}
+
+// drain reads every event buffered for ch.
+func drain(ch <-chan Event) []Event {
+	var got []Event
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				return got
+			}
+			got = append(got, e)
+		default:
+			return got
+		}
+	}
+}
+
+func TestHubDelivers(t *testing.T) {
+	bus := NewInMemoryBus()
+	defer bus.Close()
+	a, unsubscribeA := bus.Subscribe()
+	defer unsubscribeA()
+	b, unsubscribeB := bus.Subscribe()
+	defer unsubscribeB()
+
+	bus.Publish(context.Background(), rated(1))
+	bus.Publish(context.Background(), rated(2))
+
+	for name, ch := range map[string]<-chan Event{"a": a, "b": b} {
+		got := drain(ch)
+		if len(got) != 2 || got[0].UserID != 1 || got[1].UserID != 2 {
+			t.Errorf("subscriber %s got %+v, want users 1 and 2 in order", name, got)
+		}
+	}
+}
+
+func TestHubSlowSubscriber(t *testing.T) {
+	bus := NewInMemoryBus()
+	defer bus.Close()
+	slow, unsubscribeSlow := bus.Subscribe()
+	defer unsubscribeSlow()
+	fast, unsubscribeFast := bus.Subscribe()
+	defer unsubscribeFast()
+
+	ctx := context.Background()
+	for i := range subscriberBuffer + 1 {
+		bus.Publish(ctx, rated(i+1))
+		// The fast subscriber keeps up.
+		if got := drain(fast); len(got) != 1 {
+			t.Fatalf("fast subscriber got %d events, want 1", len(got))
+		}
+	}
+
+	got := drain(slow)
+	if len(got) != subscriberBuffer {
+		t.Fatalf("slow subscriber got %d events, want the %d that fit its buffer", len(got), subscriberBuffer)
+	}
+
+	// The next event is preceded by a reset for the one that was dropped.
+	bus.Publish(ctx, rated(1000))
+	got = drain(slow)
+	if len(got) != 2 || got[0].Type != LeaderboardReset || got[0].Version != 0 || got[1].UserID != 1000 {
+		t.Fatalf("slow subscriber got %+v, want a reset and then user 1000", got)
+	}
+	bus.Publish(ctx, rated(1001))
+	if got := drain(slow); len(got) != 1 || got[0].UserID != 1001 {
+		t.Errorf("slow subscriber got %+v after catching up, want user 1001 only", got)
+	}
+	if got := drain(fast); len(got) != 2 || got[0].Type == LeaderboardReset {
+		t.Errorf("fast subscriber got %+v, want two updates and no reset", got)
+	}
+}
+
+func TestHubUnsubscribe(t *testing.T) {
+	bus := NewInMemoryBus()
+	defer bus.Close()
+	ch, unsubscribe := bus.Subscribe()
+	other, unsubscribeOther := bus.Subscribe()
+	defer unsubscribeOther()
+
+	unsubscribe()
+	unsubscribe() // releasing twice is harmless
+	bus.Publish(context.Background(), rated(1))
+
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after unsubscribing")
+	}
+	if got := drain(other); len(got) != 1 {
+		t.Errorf("remaining subscriber got %d events, want 1", len(got))
+	}
+}
+
+func TestHubClose(t *testing.T) {
+	bus := NewInMemoryBus()
+	ch, unsubscribe := bus.Subscribe()
+
+	bus.Close()
+	if _, ok := <-ch; ok {
+		t.Error("channel still open after the bus closed")
+	}
+	unsubscribe() // after close is harmless
+	bus.Close()   // so is closing twice
+
+	late, _ := bus.Subscribe()
+	if _, ok := <-late; ok {
+		t.Error("subscription made after close is open")
+	}
+	bus.Publish(context.Background(), rated(1))
+}
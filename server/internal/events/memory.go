@@ -0,0 +1,27 @@
+package events
+
+import "context"
+
+// InMemoryBus delivers events to subscribers of the current process only.
+// It is used when Redis is not available.
+type InMemoryBus struct {
+	hub *hub
+}
+
+func NewInMemoryBus() *InMemoryBus {
+	return &InMemoryBus{hub: newHub()}
+}
+
+func (b *InMemoryBus) Publish(ctx context.Context, e Event) error {
+	b.hub.broadcast(e)
+	return nil
+}
+
+func (b *InMemoryBus) Subscribe() (<-chan Event, func()) {
+	return b.hub.subscribe()
+}
+
+func (b *InMemoryBus) Close() error {
+	b.hub.close()
+	return nil
+}
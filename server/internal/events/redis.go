@@ -0,0 +1,82 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"leaderboard/internal/logging"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var logger = logging.Logger("events")
+
+const DefaultChannel = "leaderboard_events"
+
+// RedisBus publishes events through Redis Pub/Sub so that every server
+// instance receives them. Events published by this instance are delivered
+// to local subscribers the same way, after the round trip through Redis.
+type RedisBus struct {
+	rdb     *redis.Client
+	channel string
+	hub     *hub
+	pubsub  *redis.PubSub
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewRedisBus(rdb *redis.Client, channel string) (*RedisBus, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pubsub := rdb.Subscribe(ctx, channel)
+	// Wait for the subscription to be confirmed so no event published
+	// after construction is missed.
+	if _, err := pubsub.Receive(ctx); err != nil {
+		cancel()
+		pubsub.Close()
+		return nil, err
+	}
+
+	b := &RedisBus{
+		rdb:     rdb,
+		channel: channel,
+		hub:     newHub(),
+		pubsub:  pubsub,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go b.listen()
+	return b, nil
+}
+
+func (b *RedisBus) Publish(ctx context.Context, e Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return b.rdb.Publish(ctx, b.channel, payload).Err()
+}
+
+func (b *RedisBus) Subscribe() (<-chan Event, func()) {
+	return b.hub.subscribe()
+}
+
+func (b *RedisBus) Close() error {
+	b.cancel()
+	err := b.pubsub.Close()
+	<-b.done
+	b.hub.close()
+	return err
+}
+
+func (b *RedisBus) listen() {
+	defer close(b.done)
+
+	for msg := range b.pubsub.Channel() {
+		var e Event
+		if err := json.Unmarshal([]byte(msg.Payload), &e); err != nil {
+			logger.Warn("Ignoring malformed leaderboard event", "error", err)
+			continue
+		}
+		b.hub.broadcast(e)
+	}
+}
@@ -0,0 +1,159 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/services"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// apiKeyMetadata is the metadata key carrying the API key, the gRPC
+// equivalent of the X-API-Key header.
+const apiKeyMetadata = "x-api-key"
+
+// authorizationMetadata carries player tokens as "Bearer <token>".
+const authorizationMetadata = "authorization"
+
+// Authorizer enforces the role required by each RPC, mirroring the HTTP
+// middleware. Methods without a requirement are public, but callers
+// presenting credentials are still identified on them.
+type Authorizer struct {
+	apiKeys  *services.APIKeyService
+	tokens   *auth.TokenVerifier
+	required map[string]auth.Role
+	players  map[string]bool
+}
+
+// NewAuthorizer returns an Authorizer checking API keys against apiKeys.
+// tokens verifies player tokens and may be nil when they are disabled.
+func NewAuthorizer(apiKeys *services.APIKeyService, tokens *auth.TokenVerifier) *Authorizer {
+	return &Authorizer{
+		apiKeys:  apiKeys,
+		tokens:   tokens,
+		required: make(map[string]auth.Role),
+		players:  make(map[string]bool),
+	}
+}
+
+// Require makes the given full method names, such as
+// "/leaderboard.v1.LeaderboardService/CreateUser", require at least role.
+func (a *Authorizer) Require(role auth.Role, methods ...string) {
+	for _, m := range methods {
+		a.required[m] = role
+	}
+}
+
+// AllowPlayers lets a player token stand in for the API key on the given
+// methods. Handlers are responsible for limiting players to their own user.
+func (a *Authorizer) AllowPlayers(methods ...string) {
+	for _, m := range methods {
+		a.players[m] = true
+	}
+}
+
+func (a *Authorizer) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *Authorizer) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorizedStream carries the caller's principal in the stream context.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (a *Authorizer) authorize(ctx context.Context, method string) (context.Context, error) {
+	role, ok := a.required[method]
+	if !ok {
+		return a.identify(ctx)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	keys := md.Get(apiKeyMetadata)
+	if len(keys) == 0 {
+		if a.tokens == nil || !a.players[method] {
+			return nil, status.Error(codes.Unauthenticated, "missing API key")
+		}
+		return a.authorizePlayer(ctx, md)
+	}
+
+	principal, err := a.apiKeys.Authenticate(ctx, keys[0])
+	if errors.Is(err, services.ErrInvalidAPIKey) {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+	if err != nil {
+		logger.ErrorContext(ctx, "API key lookup failed", "error", err)
+		return nil, status.Error(codes.Unavailable, "failed to authenticate")
+	}
+
+	if !principal.Role.Allows(role) {
+		return nil, status.Errorf(codes.PermissionDenied, "API key lacks the %s role", role)
+	}
+	return auth.NewContext(ctx, principal), nil
+}
+
+// identify serves a public method as the caller when valid credentials
+// are presented and anonymously otherwise. Invalid credentials are refused
+// as they are on other methods, but a failed lookup doesn't fail the call.
+func (a *Authorizer) identify(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if keys := md.Get(apiKeyMetadata); len(keys) > 0 {
+		principal, err := a.apiKeys.Authenticate(ctx, keys[0])
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			return nil, status.Error(codes.Unauthenticated, "invalid API key")
+		}
+		if err != nil {
+			logger.WarnContext(ctx, "API key lookup failed, serving anonymously", "error", err)
+			return ctx, nil
+		}
+		return auth.NewContext(ctx, principal), nil
+	}
+	if a.tokens != nil && len(md.Get(authorizationMetadata)) > 0 {
+		return a.authorizePlayer(ctx, md)
+	}
+	return ctx, nil
+}
+
+// hasCredentials reports whether md presents an API key or player token.
+func hasCredentials(md metadata.MD) bool {
+	return len(md.Get(apiKeyMetadata)) > 0 || len(md.Get(authorizationMetadata)) > 0
+}
+
+func (a *Authorizer) authorizePlayer(ctx context.Context, md metadata.MD) (context.Context, error) {
+	values := md.Get(authorizationMetadata)
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "missing API key or player token")
+	}
+
+	scheme, token, ok := strings.Cut(values[0], " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "malformed authorization metadata")
+	}
+
+	principal, err := a.tokens.Verify(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid player token")
+	}
+	return auth.NewContext(ctx, principal), nil
+}
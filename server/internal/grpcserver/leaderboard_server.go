@@ -0,0 +1,251 @@
+package grpcserver
+
+import (
+	"context"
+	leaderboardv1 "leaderboard/api/leaderboard/v1"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/events"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/services"
+	"sync"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var logger = logging.Logger("grpc")
+
+const maxBatchSize = 1000
+
+// LeaderboardServer implements the gRPC LeaderboardService on top of
+// services.LeaderboardService, so both APIs share validation and storage.
+type LeaderboardServer struct {
+	leaderboardv1.UnimplementedLeaderboardServiceServer
+
+	leaderboardService *services.LeaderboardService
+	bus                events.Bus
+
+	// draining is closed by Drain.
+	draining  chan struct{}
+	drainOnce sync.Once
+}
+
+func NewLeaderboardServer(leaderboardService *services.LeaderboardService, bus events.Bus) *LeaderboardServer {
+	return &LeaderboardServer{
+		leaderboardService: leaderboardService,
+		bus:                bus,
+		draining:           make(chan struct{}),
+	}
+}
+
+// Drain ends every WatchRatingChanges stream, so they don't hold up a
+// graceful stop. Clients see Unavailable and can reconnect elsewhere.
+func (s *LeaderboardServer) Drain() {
+	s.drainOnce.Do(func() { close(s.draining) })
+}
+
+func (s *LeaderboardServer) CreateUser(ctx context.Context, req *leaderboardv1.CreateUserRequest) (*leaderboardv1.CreateUserResponse, error) {
+	user, err := s.leaderboardService.CreateUser(ctx, req.GetUsername(), int(req.GetRating()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &leaderboardv1.CreateUserResponse{
+		User: &leaderboardv1.User{
+			Id:       int64(user.ID),
+			Username: user.Username,
+			Rating:   int64(user.Rating),
+		},
+	}, nil
+}
+
+func (s *LeaderboardServer) UpdateRating(ctx context.Context, req *leaderboardv1.UpdateRatingRequest) (*leaderboardv1.UpdateRatingResponse, error) {
+	if p, ok := auth.FromContext(ctx); ok && !p.CanActFor(int(req.GetUserId())) {
+		return nil, status.Error(codes.PermissionDenied, "player tokens may only change their own user")
+	}
+	if _, err := s.leaderboardService.UpdateRating(ctx, int(req.GetUserId()), int(req.GetRating()), 0); err != nil {
+		return nil, toStatus(err)
+	}
+	return &leaderboardv1.UpdateRatingResponse{}, nil
+}
+
+func (s *LeaderboardServer) BatchUpdateRatings(ctx context.Context, req *leaderboardv1.BatchUpdateRatingsRequest) (*leaderboardv1.BatchUpdateRatingsResponse, error) {
+	if len(req.GetUpdates()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "updates are required")
+	}
+	if len(req.GetUpdates()) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d updates per batch", maxBatchSize)
+	}
+
+	updates := make([]services.RatingUpdate, len(req.GetUpdates()))
+	for i, u := range req.GetUpdates() {
+		updates[i] = services.RatingUpdate{UserID: int(u.GetUserId()), Rating: int(u.GetRating())}
+	}
+
+	errs := s.leaderboardService.UpdateRatings(ctx, updates)
+
+	results := make([]*leaderboardv1.BatchUpdateRatingsResponse_Result, len(updates))
+	for i, u := range updates {
+		results[i] = &leaderboardv1.BatchUpdateRatingsResponse_Result{UserId: int64(u.UserID)}
+		if errs[i] != nil {
+			results[i].Error = status.Convert(toStatus(errs[i])).Message()
+		}
+	}
+	return &leaderboardv1.BatchUpdateRatingsResponse{Results: results}, nil
+}
+
+func (s *LeaderboardServer) GetLeaderboard(ctx context.Context, req *leaderboardv1.GetLeaderboardRequest) (*leaderboardv1.GetLeaderboardResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = 50 // Default limit
+	}
+	offset := max(int(req.GetOffset()), 0)
+
+	version, err := s.leaderboardService.LeaderboardVersion(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	users, err := s.leaderboardService.GetLeaderboard(ctx, limit, offset)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &leaderboardv1.GetLeaderboardResponse{Users: toUsers(users), Version: version}, nil
+}
+
+func (s *LeaderboardServer) SearchUsers(ctx context.Context, req *leaderboardv1.SearchUsersRequest) (*leaderboardv1.SearchUsersResponse, error) {
+	users, err := s.leaderboardService.SearchUsers(ctx, req.GetUsername())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &leaderboardv1.SearchUsersResponse{Users: toUsers(users)}, nil
+}
+
+func (s *LeaderboardServer) GetUserRank(ctx context.Context, req *leaderboardv1.GetUserRankRequest) (*leaderboardv1.GetUserRankResponse, error) {
+	user, err := s.leaderboardService.GetUserWithRank(ctx, int(req.GetUserId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &leaderboardv1.GetUserRankResponse{User: toUser(*user)}, nil
+}
+
+func (s *LeaderboardServer) WatchRatingChanges(req *leaderboardv1.WatchRatingChangesRequest, stream leaderboardv1.LeaderboardService_WatchRatingChangesServer) error {
+	ch, unsubscribe := s.bus.Subscribe()
+	defer unsubscribe()
+
+	var only map[int]bool
+	if len(req.GetUserIds()) > 0 {
+		only = make(map[int]bool, len(req.GetUserIds()))
+		for _, id := range req.GetUserIds() {
+			only[int(id)] = true
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-s.draining:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case e, ok := <-ch:
+			if !ok {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			// Resets concern every user, so they are never filtered out.
+			if only != nil && e.Type != events.LeaderboardReset && !only[e.UserID] {
+				continue
+			}
+			if err := stream.Send(toChange(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toUser(u repository.UserWithRank) *leaderboardv1.User {
+	return &leaderboardv1.User{
+		Id:       int64(u.ID),
+		Username: u.Username,
+		Rating:   int64(u.Rating),
+		Rank:     int64(u.Rank),
+	}
+}
+
+func toUsers(users []repository.UserWithRank) []*leaderboardv1.User {
+	out := make([]*leaderboardv1.User, len(users))
+	for i, u := range users {
+		out[i] = toUser(u)
+	}
+	return out
+}
+
+var changeTypes = map[events.Type]leaderboardv1.WatchRatingChangesResponse_Type{
+	events.UserCreated:      leaderboardv1.WatchRatingChangesResponse_TYPE_USER_CREATED,
+	events.RatingUpdated:    leaderboardv1.WatchRatingChangesResponse_TYPE_RATING_UPDATED,
+	events.LeaderboardReset: leaderboardv1.WatchRatingChangesResponse_TYPE_LEADERBOARD_RESET,
+}
+
+func toChange(e events.Event) *leaderboardv1.WatchRatingChangesResponse {
+	return &leaderboardv1.WatchRatingChangesResponse{
+		Type:      changeTypes[e.Type],
+		Version:   e.Version,
+		UserId:    int64(e.UserID),
+		Username:  e.Username,
+		OldRating: int64(e.OldRating),
+		NewRating: int64(e.NewRating),
+		Time:      timestamppb.New(e.At),
+	}
+}
+
+// toStatus maps service errors to gRPC statuses, the equivalent of the
+// problem responses of the HTTP API. The machine-readable code is attached
+// as the reason of an ErrorInfo detail, and invalid fields as a BadRequest
+// detail.
+func toStatus(err error) error {
+	e, ok := apperr.As(err)
+	if !ok || e.Kind == apperr.KindInternal {
+		logger.Error("gRPC call failed", "error", err)
+		return status.Error(codes.Internal, "an unexpected error occurred")
+	}
+
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: e.Code, Domain: "leaderboard"}}
+	if len(e.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(e.Fields))
+		for i, f := range e.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message, Reason: f.Code}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+
+	st := status.New(grpcCode(e.Kind), e.Message)
+	if detailed, err := st.WithDetails(details...); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+func grpcCode(kind apperr.Kind) codes.Code {
+	switch kind {
+	case apperr.KindValidation:
+		return codes.InvalidArgument
+	case apperr.KindNotFound:
+		return codes.NotFound
+	case apperr.KindConflict:
+		return codes.AlreadyExists
+	case apperr.KindPrecondition:
+		return codes.FailedPrecondition
+	case apperr.KindUnavailable:
+		return codes.Unavailable
+	case apperr.KindTimeout:
+		return codes.DeadlineExceeded
+	case apperr.KindCanceled:
+		return codes.Canceled
+	default:
+		return codes.Internal
+	}
+}
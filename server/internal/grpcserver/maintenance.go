@@ -0,0 +1,36 @@
+package grpcserver
+
+import (
+	"context"
+	"leaderboard/internal/services"
+
+	"google.golang.org/grpc"
+)
+
+// MaintenanceMode fails calls of the methods it is applied to with
+// Unavailable while maintenance mode is on, mirroring the HTTP middleware.
+type MaintenanceMode struct {
+	maintenance *services.Maintenance
+	methods     map[string]bool
+}
+
+func NewMaintenanceMode(maintenance *services.Maintenance) *MaintenanceMode {
+	return &MaintenanceMode{maintenance: maintenance, methods: make(map[string]bool)}
+}
+
+// Apply turns calls of the given full method names away during
+// maintenance.
+func (m *MaintenanceMode) Apply(methods ...string) {
+	for _, method := range methods {
+		m.methods[method] = true
+	}
+}
+
+func (m *MaintenanceMode) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if m.methods[info.FullMethod] {
+		if err := m.maintenance.Check(); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return handler(ctx, req)
+}
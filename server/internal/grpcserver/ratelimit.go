@@ -0,0 +1,142 @@
+package grpcserver
+
+import (
+	"context"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/ratelimit"
+	"maps"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// RateLimiter limits how often each client may call groups of methods,
+// mirroring the HTTP middleware and sharing its buckets when given the same
+// Limiter. Authenticated clients are counted by API key or player, everyone
+// else by IP address, so it must run after the Authorizer to see the
+// caller. A stream takes one token when it is opened.
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	// groups maps full method names to the name of their group.
+	groups map[string]string
+	// credentials is the group of calls presenting credentials to methods
+	// without a group of their own, if any.
+	credentials string
+	limits      atomic.Pointer[map[string]ratelimit.Limit]
+	// trustProxy takes the client IP from the x-forwarded-for metadata
+	// instead of the connection.
+	trustProxy atomic.Bool
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, trustProxy bool) *RateLimiter {
+	l := &RateLimiter{limiter: limiter, groups: make(map[string]string)}
+	l.limits.Store(&map[string]ratelimit.Limit{})
+	l.trustProxy.Store(trustProxy)
+	return l
+}
+
+// Limit applies limit to the given full method names. Methods of the same
+// group share one bucket per client.
+func (l *RateLimiter) Limit(group string, limit ratelimit.Limit, methods ...string) {
+	for _, m := range methods {
+		l.groups[m] = group
+	}
+	l.SetLimits(map[string]ratelimit.Limit{group: limit})
+}
+
+// LimitCredentials applies limit to every call presenting an API key or
+// player token to a method without a group of its own. In front of the
+// Authorizer this limits attempts to guess credentials per IP address.
+func (l *RateLimiter) LimitCredentials(group string, limit ratelimit.Limit) {
+	l.credentials = group
+	l.SetLimits(map[string]ratelimit.Limit{group: limit})
+}
+
+// SetLimits changes the limits of the given groups at once.
+func (l *RateLimiter) SetLimits(limits map[string]ratelimit.Limit) {
+	next := maps.Clone(*l.limits.Load())
+	maps.Copy(next, limits)
+	l.limits.Store(&next)
+}
+
+// SetTrustProxy changes whether x-forwarded-for identifies clients.
+func (l *RateLimiter) SetTrustProxy(trustProxy bool) {
+	l.trustProxy.Store(trustProxy)
+}
+
+func (l *RateLimiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	retryAfter, err := l.allow(ctx, info.FullMethod)
+	if err != nil {
+		grpc.SetHeader(ctx, retryAfter)
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (l *RateLimiter) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	retryAfter, err := l.allow(ss.Context(), info.FullMethod)
+	if err != nil {
+		ss.SetHeader(retryAfter)
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// allow counts a call of method. When the call is over the limit it
+// returns a ResourceExhausted error and the retry-after header to send.
+func (l *RateLimiter) allow(ctx context.Context, method string) (metadata.MD, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	group, ok := l.groups[method]
+	if !ok && l.credentials != "" && hasCredentials(md) {
+		group, ok = l.credentials, true
+	}
+	limit := (*l.limits.Load())[group]
+	if !ok || !limit.Enabled() {
+		return nil, nil
+	}
+
+	res, err := l.limiter.Allow(ctx, group+":"+l.clientKey(ctx, md), limit)
+	if err != nil {
+		// Rather serve the call than fail it over bookkeeping.
+		logger.ErrorContext(ctx, "Rate limiter failed", "error", err)
+		return nil, nil
+	}
+	if res.Allowed {
+		return nil, nil
+	}
+	retryAfter := strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds())))
+	return metadata.Pairs("retry-after", retryAfter), status.Error(codes.ResourceExhausted, "rate limit exceeded")
+}
+
+// clientKey identifies the caller the way the HTTP middleware does: the
+// API key or player when authenticated, the IP address otherwise.
+func (l *RateLimiter) clientKey(ctx context.Context, md metadata.MD) string {
+	if p, ok := auth.FromContext(ctx); ok {
+		return p.ClientKey()
+	}
+	if l.trustProxy.Load() {
+		// The proxy in front of us appends the address it saw, so the last
+		// entry is the only one a client can't forge.
+		if fwd := md.Get("x-forwarded-for"); len(fwd) > 0 {
+			parts := strings.Split(fwd[len(fwd)-1], ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return "ip:" + ip
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			return "ip:" + host
+		}
+		return "ip:" + p.Addr.String()
+	}
+	return "ip:unknown"
+}
@@ -0,0 +1,99 @@
+package grpcserver
+
+import (
+	"context"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/ratelimit"
+	"leaderboard/internal/services"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testMethod  = "/leaderboard.v1.LeaderboardService/GetLeaderboard"
+	testPrivate = "/leaderboard.v1.LeaderboardService/CreateUser"
+)
+
+// callContext is the context of a call from 192.0.2.1 with metadata md.
+func callContext(md metadata.MD) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 4321}})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// call runs interceptors in order, as the server chains them, around a
+// handler returning the client key of the call.
+func call(ctx context.Context, method string, l *RateLimiter, interceptors ...grpc.UnaryServerInterceptor) (string, error) {
+	handler := func(ctx context.Context, req any) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		return l.clientKey(ctx, md), nil
+	}
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		next, interceptor := handler, interceptors[i]
+		handler = func(ctx context.Context, req any) (any, error) {
+			return interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, next)
+		}
+	}
+	res, err := handler(ctx, nil)
+	key, _ := res.(string)
+	return key, err
+}
+
+func TestRateLimiter(t *testing.T) {
+	authorizer := NewAuthorizer(services.NewAPIKeyService(nil, "bootstrap-key"), nil)
+	authorizer.Require(auth.RoleScoreWriter, testPrivate)
+
+	tests := []struct {
+		name    string
+		md      metadata.MD
+		trust   bool
+		wantKey string
+	}{
+		{name: "anonymous", wantKey: "ip:192.0.2.1"},
+		{name: "api key on a public method", md: metadata.Pairs(apiKeyMetadata, "bootstrap-key"), wantKey: "key:bootstrap"},
+		{name: "untrusted proxy", md: metadata.Pairs("x-forwarded-for", "198.51.100.7"), wantKey: "ip:192.0.2.1"},
+		{name: "trusted proxy", md: metadata.Pairs("x-forwarded-for", "203.0.113.5, 198.51.100.7"), trust: true, wantKey: "ip:198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(ratelimit.NewMemoryLimiter(), tt.trust)
+			l.Limit("reads", ratelimit.Limit{Rate: 0.001, Burst: 1}, testMethod)
+
+			key, err := call(callContext(tt.md), testMethod, l, authorizer.UnaryInterceptor, l.UnaryInterceptor)
+			if err != nil || key != tt.wantKey {
+				t.Fatalf("first call = %q, %v, want %q", key, err, tt.wantKey)
+			}
+			_, err = call(callContext(tt.md), testMethod, l, authorizer.UnaryInterceptor, l.UnaryInterceptor)
+			if status.Code(err) != codes.ResourceExhausted {
+				t.Errorf("second call = %v, want ResourceExhausted", err)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCredentials(t *testing.T) {
+	authorizer := NewAuthorizer(services.NewAPIKeyService(nil, "bootstrap-key"), nil)
+	l := NewRateLimiter(ratelimit.NewMemoryLimiter(), false)
+	l.LimitCredentials("auth", ratelimit.Limit{Rate: 0.001, Burst: 1})
+
+	// Anonymous calls don't count as attempts.
+	for range 3 {
+		if _, err := call(callContext(nil), testMethod, l, l.UnaryInterceptor, authorizer.UnaryInterceptor); err != nil {
+			t.Fatalf("anonymous call = %v", err)
+		}
+	}
+	// Attempts are counted per IP address, whatever the key, and invalid
+	// keys are refused on public methods too.
+	if _, err := call(callContext(metadata.Pairs(apiKeyMetadata, "wrong")), testMethod, l, l.UnaryInterceptor, authorizer.UnaryInterceptor); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("first attempt = %v, want Unauthenticated", err)
+	}
+	if _, err := call(callContext(metadata.Pairs(apiKeyMetadata, "bootstrap-key")), testMethod, l, l.UnaryInterceptor, authorizer.UnaryInterceptor); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("second attempt = %v, want ResourceExhausted", err)
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/models"
+	"leaderboard/internal/problem"
+	"leaderboard/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AdminHandler struct {
+	apiKeyService *services.APIKeyService
+}
+
+func NewAdminHandler(apiKeyService *services.APIKeyService) *AdminHandler {
+	return &AdminHandler{apiKeyService: apiKeyService}
+}
+
+type issueAPIKeyRequest struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type apiKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Prefix     string     `json:"prefix"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+type issuedAPIKeyResponse struct {
+	apiKeyResponse
+	// Key is only ever returned once, when the key is issued.
+	Key string `json:"key"`
+}
+
+func newAPIKeyResponse(k *models.APIKey) apiKeyResponse {
+	return apiKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Role:       k.Role,
+		Prefix:     k.Prefix,
+		CreatedAt:  k.CreatedAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
+
+func (h *AdminHandler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req issueAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	role, err := auth.ParseRole(req.Role)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "role_invalid", "Invalid role")
+		return
+	}
+
+	key, raw, err := h.apiKeyService.Issue(r.Context(), req.Name, role)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, envelope{Data: issuedAPIKeyResponse{
+		apiKeyResponse: newAPIKeyResponse(key),
+		Key:            raw,
+	}})
+}
+
+func (h *AdminHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.apiKeyService.List(r.Context())
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	out := make([]apiKeyResponse, len(keys))
+	for i := range keys {
+		out[i] = newAPIKeyResponse(&keys[i])
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: out, Meta: countMeta{Count: len(out)}})
+}
+
+func (h *AdminHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_id", "Invalid id")
+		return
+	}
+
+	if err := h.apiKeyService.Revoke(r.Context(), id); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"leaderboard/internal/config"
+	"leaderboard/internal/problem"
+	"net/http"
+	"time"
+)
+
+type ConfigHandler struct {
+	reloader *config.Reloader
+}
+
+func NewConfigHandler(reloader *config.Reloader) *ConfigHandler {
+	return &ConfigHandler{reloader: reloader}
+}
+
+type configResponse struct {
+	Version  int              `json:"version"`
+	LoadedAt time.Time        `json:"loaded_at"`
+	Settings []config.Setting `json:"settings"`
+}
+
+func newConfigResponse(active config.Active) configResponse {
+	return configResponse{
+		Version:  active.Version,
+		LoadedAt: active.LoadedAt,
+		Settings: active.Config.Settings(),
+	}
+}
+
+// GetConfig returns the configuration in use and its version, with secrets
+// redacted.
+func (h *ConfigHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, envelope{Data: newConfigResponse(h.reloader.Active())})
+}
+
+// ReloadConfig reloads the configuration, like SIGHUP, and returns the
+// configuration in use afterwards.
+func (h *ConfigHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	active, err := h.reloader.Reload()
+	if err != nil {
+		problem.Write(w, r, http.StatusUnprocessableEntity, "config_invalid", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: newConfigResponse(active)})
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"leaderboard/internal/models"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/services"
+	"net/http"
+	"time"
+)
+
+// The types in this file are the response bodies of the v1 API. They are
+// kept separate from the models so storage changes never leak into the
+// public contract.
+
+type envelope struct {
+	Data any `json:"data"`
+	Meta any `json:"meta,omitempty"`
+}
+
+type userResponse struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Rating    int       `json:"rating"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type userProfileResponse struct {
+	userResponse
+	Rank int `json:"rank"`
+}
+
+type rankedUserResponse struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Rating   int    `json:"rating"`
+	Rank     int    `json:"rank"`
+}
+
+type paginationMeta struct {
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+	Count  int `json:"count"`
+	// NextOffset is null on the last page.
+	NextOffset *int `json:"next_offset"`
+}
+
+type leaderboardMeta struct {
+	Version    int64          `json:"version"`
+	Pagination paginationMeta `json:"pagination"`
+}
+
+type versionMeta struct {
+	Version int64 `json:"version"`
+}
+
+type countMeta struct {
+	Count int `json:"count"`
+}
+
+type leaderboardChangesResponse struct {
+	Since       int64                `json:"since"`
+	FullRefresh bool                 `json:"full_refresh"`
+	Changes     []rankedUserResponse `json:"changes"`
+	Removed     []int                `json:"removed"`
+}
+
+func newUserResponse(u *models.User) userResponse {
+	return userResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Rating:    u.Rating,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+func newUserProfileResponse(u *repository.UserWithRank) userProfileResponse {
+	return userProfileResponse{userResponse: newUserResponse(&u.User), Rank: u.Rank}
+}
+
+func newRankedUserResponses(users []repository.UserWithRank) []rankedUserResponse {
+	out := make([]rankedUserResponse, len(users))
+	for i, u := range users {
+		out[i] = rankedUserResponse{
+			ID:       u.ID,
+			Username: u.Username,
+			Rating:   u.Rating,
+			Rank:     u.Rank,
+		}
+	}
+	return out
+}
+
+func newPaginationMeta(limit, offset, count int) paginationMeta {
+	meta := paginationMeta{Limit: limit, Offset: offset, Count: count}
+	if count >= limit {
+		next := offset + count
+		meta.NextOffset = &next
+	}
+	return meta
+}
+
+func newLeaderboardChangesResponse(delta *services.LeaderboardDelta) leaderboardChangesResponse {
+	removed := delta.Removed
+	if removed == nil {
+		removed = []int{}
+	}
+	return leaderboardChangesResponse{
+		Since:       delta.Since,
+		FullRefresh: delta.FullRefresh,
+		Changes:     newRankedUserResponses(delta.Changes),
+		Removed:     removed,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
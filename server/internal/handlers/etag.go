@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// leaderboardETag identifies one page of the leaderboard at one version.
+func leaderboardETag(version int64, limit, offset int) string {
+	return fmt.Sprintf(`"v%d-l%d-o%d"`, version, limit, offset)
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag,
+// using the weak comparison required for If-None-Match (RFC 9110 13.1.2).
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// userETag identifies one version of a user record.
+func userETag(userID, version int) string {
+	return fmt.Sprintf(`"u%d-v%d"`, userID, version)
+}
+
+// ifMatchVersion reads the user version an update is conditional on from
+// the If-Match header, using the strong comparison required for If-Match
+// (RFC 9110 13.1.1). It returns 0 when the update is unconditional, and
+// false when the header can't match any version of the user.
+func ifMatchVersion(header string, userID int) (int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	prefix := fmt.Sprintf(`"u%d-v`, userID)
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		rest, ok := strings.CutPrefix(candidate, prefix)
+		if !ok {
+			continue
+		}
+		digits, ok := strings.CutSuffix(rest, `"`)
+		if !ok || strings.Trim(digits, "0123456789") != "" {
+			continue
+		}
+		if version, err := strconv.Atoi(digits); err == nil && version > 0 {
+			return version, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import "testing"
+
+func TestETagMatches(t *testing.T) {
+	etag := leaderboardETag(7, 50, 0)
+
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: "*", want: true},
+		{header: " * ", want: true},
+		{header: `"v7-l50-o0"`, want: true},
+		{header: `W/"v7-l50-o0"`, want: true},
+		{header: `"v6-l50-o0"`, want: false},
+		{header: `"v7-l50-o50"`, want: false},
+		{header: `"v6-l50-o0", "v7-l50-o0"`, want: true},
+		{header: `"v6-l50-o0",W/"v7-l50-o0"`, want: true},
+		{header: `"v6-l50-o0", "v8-l50-o0"`, want: false},
+		{header: `v7-l50-o0`, want: false},
+		{header: `"v7-l50-o0`, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, etag); got != tt.want {
+			t.Errorf("etagMatches(%q, %q) = %v, want %v", tt.header, etag, got, tt.want)
+		}
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header      string
+		wantVersion int
+		wantOK      bool
+	}{
+		{header: "", wantVersion: 0, wantOK: true},
+		{header: "*", wantVersion: 0, wantOK: true},
+		{header: ` "u7-v3" `, wantVersion: 3, wantOK: true},
+		{header: `"u7-v3"`, wantVersion: 3, wantOK: true},
+		{header: `"u8-v3", "u7-v4"`, wantVersion: 4, wantOK: true},
+		{header: `"u7-v3","u7-v4"`, wantVersion: 3, wantOK: true},
+		// If-Match uses the strong comparison, so weak tags never match.
+		{header: `W/"u7-v3"`, wantOK: false},
+		{header: `"u8-v3"`, wantOK: false},
+		{header: `"u77-v3"`, wantOK: false},
+		{header: `"u7-v0"`, wantOK: false},
+		{header: `"u7-v-1"`, wantOK: false},
+		{header: `"u7-v+3"`, wantOK: false},
+		{header: `"u7-v3x"`, wantOK: false},
+		{header: `"u7-v3`, wantOK: false},
+		{header: `u7-v3`, wantOK: false},
+		{header: `"u7-v"`, wantOK: false},
+		{header: `"v3-l50-o0"`, wantOK: false},
+		{header: `"u7-v99999999999999999999"`, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		version, ok := ifMatchVersion(tt.header, 7)
+		if version != tt.wantVersion || ok != tt.wantOK {
+			t.Errorf("ifMatchVersion(%q, 7) = %d, %v, want %d, %v", tt.header, version, ok, tt.wantVersion, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"leaderboard/internal/health"
+	"net/http"
+)
+
+// HealthHandler serves the probes of orchestrators and load balancers,
+// and the health report. Unlike the API, their bodies are not enveloped.
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+type probeResponse struct {
+	Status health.Status `json:"status"`
+	// Checks maps the name of each check to its status.
+	Checks map[string]health.Status `json:"checks,omitempty"`
+}
+
+// Livez reports that the process is running and serving HTTP. It checks
+// no dependency: restarting the server would not bring them back.
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, probeResponse{Status: health.StatusOK})
+}
+
+// Readyz reports whether the server should get traffic: 200 while it is
+// ok or degraded, 503 when a critical check fails or it is shutting down.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+	resp := probeResponse{Status: report.Status, Checks: make(map[string]health.Status, len(report.Checks))}
+	for _, res := range report.Checks {
+		resp.Checks[res.Name] = res.Status
+	}
+	writeJSON(w, reportStatus(report), resp)
+}
+
+// Healthz returns the full health report, with the error and duration of
+// every check, and the status code of Readyz.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+	writeJSON(w, reportStatus(report), report)
+}
+
+func reportStatus(report health.Report) int {
+	if report.Status == health.StatusUnavailable {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
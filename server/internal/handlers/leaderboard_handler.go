@@ -1,48 +1,33 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/problem"
 	"leaderboard/internal/services"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type LeaderboardHandler struct {
 	leaderboardService *services.LeaderboardService
-	simulationService  *services.SimulationService
+	topWatcher         *services.TopWatcher
 }
 
 func NewLeaderboardHandler(
 	leaderboardService *services.LeaderboardService,
-	simulationService *services.SimulationService,
+	topWatcher *services.TopWatcher,
 ) *LeaderboardHandler {
 	return &LeaderboardHandler{
 		leaderboardService: leaderboardService,
-		simulationService:  simulationService,
+		topWatcher:         topWatcher,
 	}
 }
 
-func (h *LeaderboardHandler) StartSimulation(w http.ResponseWriter, r *http.Request) {
-	h.simulationService.Start()
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "simulation started"}`))
-}
-
-func (h *LeaderboardHandler) StopSimulation(w http.ResponseWriter, r *http.Request) {
-	h.simulationService.Stop()
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "simulation stopped"}`))
-}
-
-func (h *LeaderboardHandler) GetSimulationStatus(w http.ResponseWriter, r *http.Request) {
-	status := "stopped"
-	if h.simulationService.IsRunning() {
-		status = "running"
-	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": status})
-}
-
 type createUserRequest struct {
 	Username string `json:"username"`
 	Rating   int    `json:"rating"`
@@ -51,13 +36,13 @@ type createUserRequest struct {
 func (h *LeaderboardHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req createUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
 		return
 	}
 
-	user, err := h.leaderboardService.CreateUser(req.Username, req.Rating)
+	user, err := h.leaderboardService.CreateUser(r.Context(), req.Username, req.Rating)
 	if err != nil {
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 
@@ -74,31 +59,72 @@ func (h *LeaderboardHandler) UpdateRating(w http.ResponseWriter, r *http.Request
 
 	idStr := r.URL.Query().Get("id")
 	if idStr == "" {
-		http.Error(w, "Missing idStr", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "missing_id", "Missing id")
 		return
 	}
 
 	userId, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "Invalid id", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "invalid_id", "Invalid id")
 		return
 	}
 
+	if !h.updateRating(w, r, userId) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// updateRating applies the rating in the request body to a user, honouring
+// If-Match, and sets the ETag of the updated user. It returns false if it
+// already wrote an error response.
+func (h *LeaderboardHandler) updateRating(w http.ResponseWriter, r *http.Request, userId int) bool {
+	if !canActFor(w, r, userId) {
+		return false
+	}
+
+	ifVersion, ok := ifMatchVersion(r.Header.Get("If-Match"), userId)
+	if !ok {
+		problem.Write(w, r, http.StatusPreconditionFailed, "version_conflict", "If-Match does not match the user")
+		return false
+	}
+
 	var req updateRatingRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return false
 	}
 
-	if err := h.leaderboardService.UpdateRating(userId, req.Rating); err != nil {
-		http.Error(w, "Failed to update rating", http.StatusInternalServerError)
-		return
+	user, err := h.leaderboardService.UpdateRating(r.Context(), userId, req.Rating, ifVersion)
+	if err != nil {
+		problem.Error(w, r, err)
+		return false
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	w.Header().Set("ETag", userETag(user.ID, user.Version))
+	return true
 }
 
 func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+
+	if _, notModified := h.checkNotModified(w, r, limit, offset); notModified {
+		return
+	}
+
+	users, err := h.leaderboardService.GetLeaderboard(r.Context(), limit, offset)
+
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(users)
+}
+
+func parsePagination(r *http.Request) (int, int) {
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
 
@@ -111,31 +137,174 @@ func (h *LeaderboardHandler) GetLeaderboard(w http.ResponseWriter, r *http.Reque
 	if offset < 0 {
 		offset = 0
 	}
+	return limit, offset
+}
+
+// checkNotModified sets the ETag of the requested page and answers
+// conditional requests from the leaderboard version alone, before any ranks
+// are computed. It returns the version and whether the response was
+// written. If the version can't be read the page is just served.
+func (h *LeaderboardHandler) checkNotModified(w http.ResponseWriter, r *http.Request, limit, offset int) (int64, bool) {
+	version, err := h.leaderboardService.LeaderboardVersion(r.Context())
+	if err != nil {
+		return 0, false
+	}
 
-	users, err := h.leaderboardService.GetLeaderboard(limit, offset)
+	etag := leaderboardETag(version, limit, offset)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
 
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return version, true
+	}
+	return version, false
+}
+
+func (h *LeaderboardHandler) GetLeaderboardChanges(w http.ResponseWriter, r *http.Request) {
+	delta, ok := h.leaderboardChanges(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(delta)
+}
+
+func (h *LeaderboardHandler) leaderboardChanges(w http.ResponseWriter, r *http.Request) (*services.LeaderboardDelta, bool) {
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	if err != nil || since < 0 {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_since", "Invalid since")
+		return nil, false
+	}
+
+	start, stop, err := parseRange(r.URL.Query().Get("range"))
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "range_invalid", "Invalid range")
+		return nil, false
+	}
+
+	delta, err := h.leaderboardService.GetChangesSince(r.Context(), since, start, stop)
 	if err != nil {
-		http.Error(w, "Failed to get leaderboard", http.StatusInternalServerError)
+		problem.Error(w, r, err)
+		return nil, false
+	}
+	return delta, true
+}
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 60 * time.Second
+)
+
+// WaitForLeaderboard long-polls the top of the leaderboard: it responds as
+// soon as the first page differs from what the client saw at version, or
+// with 304 once the timeout elapses without a change.
+func (h *LeaderboardHandler) WaitForLeaderboard(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.waitForLeaderboard(w, r)
+	if !ok {
 		return
 	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(users)
+	json.NewEncoder(w).Encode(page)
+}
+
+// waitForLeaderboard parses a long-poll request and waits for the top of
+// the leaderboard to change. It returns false when it has already written
+// the response, including the 304 sent when nothing changed in time.
+func (h *LeaderboardHandler) waitForLeaderboard(w http.ResponseWriter, r *http.Request) (*services.TopPage, bool) {
+	version, err := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_version", "Invalid version")
+		return nil, false
+	}
+
+	timeout := defaultWaitTimeout
+	if timeoutStr := r.URL.Query().Get("timeout"); timeoutStr != "" {
+		seconds, err := strconv.Atoi(timeoutStr)
+		if err != nil || seconds <= 0 {
+			problem.Write(w, r, http.StatusBadRequest, "invalid_timeout", "Invalid timeout")
+			return nil, false
+		}
+		timeout = min(time.Duration(seconds)*time.Second, maxWaitTimeout)
+	}
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50 // Default limit
+	}
+	limit = min(limit, services.TopSize)
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	page, err := h.topWatcher.Wait(ctx, version, limit)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyWaiters) {
+			w.Header().Set("Retry-After", "5")
+		}
+		problem.Error(w, r, err)
+		return nil, false
+	}
+	if page == nil {
+		w.WriteHeader(http.StatusNotModified)
+		return nil, false
+	}
+	return page, true
+}
+
+// parseRange parses a zero based, inclusive rank range such as "0-99".
+// An empty value selects the default first page.
+func parseRange(value string) (int, int, error) {
+	if value == "" {
+		return 0, 49, nil
+	}
+
+	startStr, stopStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, errors.New("range must look like start-stop")
+	}
+	start, err := strconv.Atoi(startStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	stop, err := strconv.Atoi(stopStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start < 0 || stop < start {
+		return 0, 0, errors.New("range is empty")
+	}
+	return start, stop, nil
 }
 
 func (h *LeaderboardHandler) GetUserWithRank(w http.ResponseWriter, r *http.Request) {
 	username := r.URL.Query().Get("username")
 	if username == "" {
-		http.Error(w, "Missing username", http.StatusBadRequest)
+		problem.Write(w, r, http.StatusBadRequest, "username_required", "Missing username")
 		return
 	}
 
-	users, err := h.leaderboardService.SearchUsers(username)
+	users, err := h.leaderboardService.SearchUsers(r.Context(), username)
 	if err != nil {
-		http.Error(w, "Failed to search users", http.StatusInternalServerError)
+		problem.Error(w, r, err)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(users)
 }
+
+// canActFor rejects requests from players acting on another user's data
+// with a 403. Requests authenticated with an API key always pass.
+func canActFor(w http.ResponseWriter, r *http.Request, userID int) bool {
+	principal, ok := auth.FromContext(r.Context())
+	if ok && !principal.CanActFor(userID) {
+		problem.Write(w, r, http.StatusForbidden, "not_own_user", "Player tokens may only change their own user")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"encoding/json"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/problem"
+	"leaderboard/internal/services"
+	"net/http"
+	"strconv"
+)
+
+// The V1 handlers serve the versioned /v1 API. They share parsing and
+// services with the legacy handlers but respond with the DTOs in dto.go,
+// wrapped in an envelope.
+
+func (h *LeaderboardHandler) CreateUserV1(w http.ResponseWriter, r *http.Request) {
+	var req createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+
+	user, err := h.leaderboardService.CreateUser(r.Context(), req.Username, req.Rating)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("Location", "/v1/users/"+strconv.Itoa(user.ID))
+	writeJSON(w, http.StatusCreated, envelope{Data: newUserResponse(user)})
+}
+
+func (h *LeaderboardHandler) GetUserV1(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_id", "Invalid id")
+		return
+	}
+
+	user, err := h.leaderboardService.GetUserWithRank(r.Context(), userId)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user.ID, user.Version))
+	writeJSON(w, http.StatusOK, envelope{Data: newUserProfileResponse(user)})
+}
+
+// GetMe returns the profile and rank of the player the request's token was
+// issued to.
+func (h *LeaderboardHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok || !principal.IsPlayer() {
+		problem.Write(w, r, http.StatusForbidden, "player_token_required", "Requires a player token")
+		return
+	}
+
+	user, err := h.leaderboardService.GetUserWithRank(r.Context(), principal.UserID)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", userETag(user.ID, user.Version))
+	writeJSON(w, http.StatusOK, envelope{Data: newUserProfileResponse(user)})
+}
+
+func (h *LeaderboardHandler) UpdateRatingV1(w http.ResponseWriter, r *http.Request) {
+	userId, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_id", "Invalid id")
+		return
+	}
+
+	if !h.updateRating(w, r, userId) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *LeaderboardHandler) SearchUsersV1(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		problem.Write(w, r, http.StatusBadRequest, "username_required", "Missing username")
+		return
+	}
+
+	users, err := h.leaderboardService.SearchUsers(r.Context(), username)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{
+		Data: newRankedUserResponses(users),
+		Meta: countMeta{Count: len(users)},
+	})
+}
+
+func (h *LeaderboardHandler) GetLeaderboardV1(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r)
+	limit = min(limit, services.MaxPageSize)
+
+	version, notModified := h.checkNotModified(w, r, limit, offset)
+	if notModified {
+		return
+	}
+
+	users, err := h.leaderboardService.GetLeaderboard(r.Context(), limit, offset)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{
+		Data: newRankedUserResponses(users),
+		Meta: leaderboardMeta{
+			Version:    version,
+			Pagination: newPaginationMeta(limit, offset, len(users)),
+		},
+	})
+}
+
+func (h *LeaderboardHandler) GetLeaderboardChangesV1(w http.ResponseWriter, r *http.Request) {
+	delta, ok := h.leaderboardChanges(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{
+		Data: newLeaderboardChangesResponse(delta),
+		Meta: versionMeta{Version: delta.Version},
+	})
+}
+
+func (h *LeaderboardHandler) WaitForLeaderboardV1(w http.ResponseWriter, r *http.Request) {
+	page, ok := h.waitForLeaderboard(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, envelope{
+		Data: newRankedUserResponses(page.Users),
+		Meta: versionMeta{Version: page.Version},
+	})
+}
+
+// Deprecated marks a legacy route as deprecated and points clients at the
+// v1 route replacing it.
+func Deprecated(successor string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
+}
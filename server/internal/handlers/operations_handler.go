@@ -0,0 +1,385 @@
+package handlers
+
+import (
+	"encoding/json"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/models"
+	"leaderboard/internal/problem"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// OperationsHandler serves the admin routes for operations tasks: the
+// simulation, the Redis leaderboard, statistics, maintenance mode and the
+// audit log.
+type OperationsHandler struct {
+	simulationService *services.SimulationService
+	operations        *services.OperationsService
+	maintenance       *services.Maintenance
+	audit             *services.AuditService
+}
+
+func NewOperationsHandler(
+	simulationService *services.SimulationService,
+	operations *services.OperationsService,
+	maintenance *services.Maintenance,
+	audit *services.AuditService,
+) *OperationsHandler {
+	return &OperationsHandler{
+		simulationService: simulationService,
+		operations:        operations,
+		maintenance:       maintenance,
+		audit:             audit,
+	}
+}
+
+type simulationConfigResponse struct {
+	Interval          string  `json:"interval"`
+	UpdatesPerTick    int     `json:"updates_per_tick"`
+	Population        int     `json:"population"`
+	PopulationRefresh string  `json:"population_refresh"`
+	Distribution      string  `json:"distribution"`
+	MinRating         int     `json:"min_rating"`
+	MaxRating         int     `json:"max_rating"`
+	RatingMean        float64 `json:"rating_mean"`
+	RatingStdDev      float64 `json:"rating_stddev"`
+	ZipfS             float64 `json:"zipf_s"`
+	Seed              int64   `json:"seed"`
+	BurstEvery        string  `json:"burst_every"`
+	BurstDuration     string  `json:"burst_duration"`
+	BurstFactor       int     `json:"burst_factor"`
+}
+
+type simulationResponse struct {
+	Running bool                     `json:"running"`
+	Config  simulationConfigResponse `json:"config"`
+}
+
+func (h *OperationsHandler) simulationResponse() simulationResponse {
+	cfg := h.simulationService.Config()
+	return simulationResponse{
+		Running: h.simulationService.IsRunning(),
+		Config: simulationConfigResponse{
+			Interval:          cfg.Interval.String(),
+			UpdatesPerTick:    cfg.UpdatesPerTick,
+			Population:        cfg.Population,
+			PopulationRefresh: cfg.PopulationRefresh.String(),
+			Distribution:      string(cfg.Distribution),
+			MinRating:         cfg.MinRating,
+			MaxRating:         cfg.MaxRating,
+			RatingMean:        cfg.RatingMean,
+			RatingStdDev:      cfg.RatingStdDev,
+			ZipfS:             cfg.ZipfS,
+			Seed:              cfg.Seed,
+			BurstEvery:        cfg.BurstEvery.String(),
+			BurstDuration:     cfg.BurstDuration.String(),
+			BurstFactor:       cfg.BurstFactor,
+		},
+	}
+}
+
+func (h *OperationsHandler) GetSimulation(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, envelope{Data: h.simulationResponse()})
+}
+
+func (h *OperationsHandler) StartSimulation(w http.ResponseWriter, r *http.Request) {
+	h.simulationService.Start()
+	writeJSON(w, http.StatusOK, envelope{Data: h.simulationResponse()})
+}
+
+func (h *OperationsHandler) StopSimulation(w http.ResponseWriter, r *http.Request) {
+	h.simulationService.Stop()
+	writeJSON(w, http.StatusOK, envelope{Data: h.simulationResponse()})
+}
+
+// simulationConfigRequest holds the settings of a scenario; those not set
+// are nil.
+type simulationConfigRequest struct {
+	Interval          *string  `json:"interval"`
+	UpdatesPerTick    *int     `json:"updates_per_tick"`
+	Population        *int     `json:"population"`
+	PopulationRefresh *string  `json:"population_refresh"`
+	Distribution      *string  `json:"distribution"`
+	MinRating         *int     `json:"min_rating"`
+	MaxRating         *int     `json:"max_rating"`
+	RatingMean        *float64 `json:"rating_mean"`
+	RatingStdDev      *float64 `json:"rating_stddev"`
+	ZipfS             *float64 `json:"zipf_s"`
+	Seed              *int64   `json:"seed"`
+	BurstEvery        *string  `json:"burst_every"`
+	BurstDuration     *string  `json:"burst_duration"`
+	BurstFactor       *int     `json:"burst_factor"`
+}
+
+// apply sets the settings of cfg that the request sets, and checks the
+// result.
+func (req *simulationConfigRequest) apply(cfg *services.SimulationConfig) error {
+	var fields []apperr.FieldError
+	setDuration := func(dst *time.Duration, field string, v *string) {
+		if v == nil {
+			return
+		}
+		d, err := time.ParseDuration(*v)
+		if err != nil {
+			fields = append(fields, apperr.FieldError{Field: field, Code: "invalid_duration", Message: "must be a duration such as 500ms"})
+			return
+		}
+		*dst = d
+	}
+	setDuration(&cfg.Interval, "interval", req.Interval)
+	setDuration(&cfg.PopulationRefresh, "population_refresh", req.PopulationRefresh)
+	setDuration(&cfg.BurstEvery, "burst_every", req.BurstEvery)
+	setDuration(&cfg.BurstDuration, "burst_duration", req.BurstDuration)
+	if fields != nil {
+		return apperr.InvalidFields("simulation_config_invalid", "invalid simulation configuration", fields)
+	}
+
+	setIfPresent(&cfg.UpdatesPerTick, req.UpdatesPerTick)
+	setIfPresent(&cfg.Population, req.Population)
+	if req.Distribution != nil {
+		cfg.Distribution = services.RatingDistribution(*req.Distribution)
+	}
+	setIfPresent(&cfg.MinRating, req.MinRating)
+	setIfPresent(&cfg.MaxRating, req.MaxRating)
+	setIfPresent(&cfg.RatingMean, req.RatingMean)
+	setIfPresent(&cfg.RatingStdDev, req.RatingStdDev)
+	setIfPresent(&cfg.ZipfS, req.ZipfS)
+	setIfPresent(&cfg.Seed, req.Seed)
+	setIfPresent(&cfg.BurstFactor, req.BurstFactor)
+	return cfg.Validate()
+}
+
+func setIfPresent[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// SetSimulationConfig replaces the scenario of the simulation, until a
+// configuration reload changes the simulation settings. Settings left out
+// are zero.
+func (h *OperationsHandler) SetSimulationConfig(w http.ResponseWriter, r *http.Request) {
+	h.setSimulationConfig(w, r, services.SimulationConfig{})
+}
+
+// UpdateSimulationConfig changes the settings given and keeps the others,
+// until a configuration reload changes the simulation settings.
+func (h *OperationsHandler) UpdateSimulationConfig(w http.ResponseWriter, r *http.Request) {
+	h.setSimulationConfig(w, r, h.simulationService.Config())
+}
+
+func (h *OperationsHandler) setSimulationConfig(w http.ResponseWriter, r *http.Request, cfg services.SimulationConfig) {
+	var req simulationConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+	if err := req.apply(&cfg); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	h.simulationService.SetConfig(cfg)
+	writeJSON(w, http.StatusOK, envelope{Data: h.simulationResponse()})
+}
+
+// ResyncRedis rebuilds the Redis leaderboard from Postgres.
+func (h *OperationsHandler) ResyncRedis(w http.ResponseWriter, r *http.Request) {
+	if err := h.operations.Resync(r.Context()); err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Reconcile corrects the Redis leaderboard where it differs from Postgres,
+// or with ?dry_run=true only reports the differences.
+func (h *OperationsHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+	report, err := h.operations.Reconcile(r.Context(), dryRun)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: newReconcileResponse(report)})
+}
+
+type reconcileResponse struct {
+	Users   int  `json:"users"`
+	Missing int  `json:"missing"`
+	Stale   int  `json:"stale"`
+	Extra   int  `json:"extra"`
+	DryRun  bool `json:"dry_run"`
+	Fixed   bool `json:"fixed"`
+}
+
+func newReconcileResponse(r *repository.ReconcileReport) reconcileResponse {
+	return reconcileResponse{
+		Users:   r.Users,
+		Missing: r.Missing,
+		Stale:   r.Stale,
+		Extra:   r.Extra,
+		DryRun:  r.DryRun,
+		Fixed:   r.Fixed,
+	}
+}
+
+type postgresStatsResponse struct {
+	MaxOpenConnections int     `json:"max_open_connections"`
+	OpenConnections    int     `json:"open_connections"`
+	InUse              int     `json:"in_use"`
+	Idle               int     `json:"idle"`
+	WaitCount          int64   `json:"wait_count"`
+	WaitSeconds        float64 `json:"wait_seconds"`
+}
+
+type redisStatsResponse struct {
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"total_connections"`
+	IdleConns  uint32 `json:"idle_connections"`
+	StaleConns uint32 `json:"stale_connections"`
+}
+
+type cacheStatsResponse struct {
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	Loads         uint64  `json:"loads"`
+	Invalidations uint64  `json:"invalidations"`
+	Size          int     `json:"size"`
+	AgeSeconds    float64 `json:"age_seconds"`
+}
+
+type statsResponse struct {
+	Postgres postgresStatsResponse `json:"postgres"`
+	// Redis and Cache are null when disabled.
+	Redis *redisStatsResponse `json:"redis"`
+	Cache *cacheStatsResponse `json:"cache"`
+}
+
+// GetStats reports the connection pools and the leaderboard cache.
+func (h *OperationsHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats := h.operations.Stats()
+	resp := statsResponse{Postgres: postgresStatsResponse{
+		MaxOpenConnections: stats.Postgres.MaxOpenConnections,
+		OpenConnections:    stats.Postgres.OpenConnections,
+		InUse:              stats.Postgres.InUse,
+		Idle:               stats.Postgres.Idle,
+		WaitCount:          stats.Postgres.WaitCount,
+		WaitSeconds:        stats.Postgres.WaitDuration.Seconds(),
+	}}
+	if s := stats.Redis; s != nil {
+		resp.Redis = &redisStatsResponse{
+			Hits:       s.Hits,
+			Misses:     s.Misses,
+			Timeouts:   s.Timeouts,
+			TotalConns: s.TotalConns,
+			IdleConns:  s.IdleConns,
+			StaleConns: s.StaleConns,
+		}
+	}
+	if s := stats.Cache; s != nil {
+		resp.Cache = &cacheStatsResponse{
+			Hits:          s.Hits,
+			Misses:        s.Misses,
+			Loads:         s.Loads,
+			Invalidations: s.Invalidations,
+			Size:          s.Size,
+			AgeSeconds:    s.Age.Seconds(),
+		}
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: resp})
+}
+
+type maintenanceResponse struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since"`
+}
+
+func newMaintenanceResponse(status services.MaintenanceStatus) maintenanceResponse {
+	return maintenanceResponse{Enabled: status.Enabled, Message: status.Message, Since: status.Since}
+}
+
+func (h *OperationsHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, envelope{Data: newMaintenanceResponse(h.maintenance.Status())})
+}
+
+type setMaintenanceRequest struct {
+	Enabled bool   `json:"enabled"`
+	Message string `json:"message"`
+}
+
+// SetMaintenance turns maintenance mode on or off.
+func (h *OperationsHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req setMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Invalid request body")
+		return
+	}
+	status := h.maintenance.Set(req.Enabled, req.Message)
+	writeJSON(w, http.StatusOK, envelope{Data: newMaintenanceResponse(status)})
+}
+
+type auditEntryResponse struct {
+	ID        int64           `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Actor     string          `json:"actor"`
+	KeyID     *int            `json:"key_id"`
+	Action    string          `json:"action"`
+	Status    int             `json:"status"`
+	RequestID string          `json:"request_id"`
+	Details   json.RawMessage `json:"details,omitempty"`
+}
+
+func newAuditEntryResponse(e *models.AuditEntry) auditEntryResponse {
+	return auditEntryResponse{
+		ID:        e.ID,
+		CreatedAt: e.CreatedAt,
+		Actor:     e.Actor,
+		KeyID:     e.KeyID,
+		Action:    e.Action,
+		Status:    e.Status,
+		RequestID: e.RequestID,
+		Details:   e.Details,
+	}
+}
+
+type auditLogMeta struct {
+	Count int `json:"count"`
+	// NextBefore is the before parameter of the next page, null on the
+	// last one.
+	NextBefore *int64 `json:"next_before"`
+}
+
+// ListAuditLog returns the audit log, newest first, a page of ?limit
+// entries at a time. ?before continues from the next_before of the
+// previous page.
+func (h *OperationsHandler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	limit = min(limit, services.MaxAuditPageSize)
+	before, _ := strconv.ParseInt(r.URL.Query().Get("before"), 10, 64)
+
+	entries, err := h.audit.List(r.Context(), limit, before)
+	if err != nil {
+		problem.Error(w, r, err)
+		return
+	}
+
+	out := make([]auditEntryResponse, len(entries))
+	for i := range entries {
+		out[i] = newAuditEntryResponse(&entries[i])
+	}
+	meta := auditLogMeta{Count: len(out)}
+	if len(out) == limit {
+		meta.NextBefore = &out[len(out)-1].ID
+	}
+	writeJSON(w, http.StatusOK, envelope{Data: out, Meta: meta})
+}
@@ -0,0 +1,131 @@
+// Package health checks the dependencies of the server, to tell
+// orchestrators whether it is ready for traffic and humans what is wrong
+// when it isn't.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Status is the state of the server or of one of its checks.
+type Status string
+
+const (
+	StatusOK Status = "ok"
+	// StatusDegraded means a check that is not critical failed: the server
+	// still serves, with reduced performance or features.
+	StatusDegraded Status = "degraded"
+	// StatusUnavailable means a critical check failed, or the server is
+	// shutting down, and it should not get traffic.
+	StatusUnavailable Status = "unavailable"
+	// StatusFailed is the status of a check that failed.
+	StatusFailed Status = "failed"
+)
+
+// Check is a dependency of the server, such as a database, checked by Run.
+type Check struct {
+	Name string
+	// Critical checks make the server unavailable when they fail; others
+	// make it degraded.
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of a check.
+type Result struct {
+	Name       string  `json:"name"`
+	Status     Status  `json:"status"`
+	Critical   bool    `json:"critical"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// Report is the outcome of every check.
+type Report struct {
+	Status    Status    `json:"status"`
+	StartedAt time.Time `json:"started_at"`
+	// ShuttingDown is set once the server stopped taking new work.
+	ShuttingDown bool     `json:"shutting_down"`
+	Checks       []Result `json:"checks"`
+}
+
+// Checker runs checks, each with a timeout, and remembers when the server
+// started shutting down.
+type Checker struct {
+	timeout      time.Duration
+	startedAt    time.Time
+	checks       []Check
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, startedAt: time.Now()}
+}
+
+// Add registers a check. Checks must be added before Run is called.
+func (c *Checker) Add(check Check) {
+	c.checks = append(c.checks, check)
+}
+
+// ShutDown makes the server unavailable from now on, so it stops getting
+// new traffic while requests in flight finish.
+func (c *Checker) ShutDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Run runs every check at once and reports their results, in the order
+// the checks were added.
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusOK,
+		StartedAt:    c.startedAt,
+		ShuttingDown: c.shuttingDown.Load(),
+		Checks:       make([]Result, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range report.Checks {
+		switch {
+		case res.Status == StatusOK:
+		case res.Critical:
+			report.Status = StatusUnavailable
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	if report.ShuttingDown {
+		report.Status = StatusUnavailable
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	res := Result{
+		Name:       check.Name,
+		Status:     StatusOK,
+		Critical:   check.Critical,
+		DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusFailed
+		res.Error = err.Error()
+	}
+	return res
+}
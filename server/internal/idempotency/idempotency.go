@@ -0,0 +1,36 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Response is a stored response, replayed for retries of its request.
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// Record is what is stored under an idempotency key.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string `json:"fingerprint"`
+	// Response is nil while the first request is still being served.
+	Response *Response `json:"response,omitempty"`
+}
+
+// Store keeps idempotency records until they expire.
+type Store interface {
+	// Reserve claims key for a request with the given fingerprint for
+	// pendingTTL, after which a request that never completed, for example
+	// because the instance serving it crashed, no longer holds it. It
+	// returns nil if the caller now owns the key, or the existing record if
+	// the key was already used.
+	Reserve(ctx context.Context, key, fingerprint string, pendingTTL time.Duration) (*Record, error)
+	// Complete stores the response of the request owning key for ttl.
+	Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error
+	// Release gives up a reserved key so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// cleanupInterval is how often expired records are deleted.
+const cleanupInterval = time.Hour
+
+// PostgresStore keeps records in the idempotency_keys table, for
+// deployments without Redis.
+type PostgresStore struct {
+	db *gorm.DB
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+type idempotencyRow struct {
+	Fingerprint string
+	Status      *int
+	Header      []byte
+	Body        []byte
+}
+
+// Reserve implements Store.
+func (s *PostgresStore) Reserve(ctx context.Context, key, fingerprint string, pendingTTL time.Duration) (*Record, error) {
+	s.cleanup(ctx)
+
+	now := time.Now()
+	db := s.db.WithContext(ctx)
+
+	// Insert the key, or take it over if the record there has expired.
+	res := db.Exec(`
+	INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT (key) DO UPDATE
+	SET fingerprint = EXCLUDED.fingerprint, status = NULL, header = NULL, body = NULL, expires_at = EXCLUDED.expires_at
+	WHERE idempotency_keys.expires_at < ?`,
+		key, fingerprint, now.Add(pendingTTL), now)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	var row idempotencyRow
+	err := db.Raw("SELECT fingerprint, status, header, body FROM idempotency_keys WHERE key = ?", key).
+		Scan(&row).Error
+	if err != nil {
+		return nil, err
+	}
+	if row.Fingerprint == "" {
+		return nil, errors.New("idempotency key expired while being read")
+	}
+
+	rec := &Record{Fingerprint: row.Fingerprint}
+	if row.Status != nil {
+		rec.Response = &Response{Status: *row.Status, Body: row.Body}
+		if err := json.Unmarshal(row.Header, &rec.Response.Header); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+// Complete implements Store.
+func (s *PostgresStore) Complete(ctx context.Context, key string, rec Record, ttl time.Duration) error {
+	header, err := json.Marshal(rec.Response.Header)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Exec(`
+	UPDATE idempotency_keys SET status = ?, header = ?, body = ?, expires_at = ?
+	WHERE key = ?`,
+		rec.Response.Status, header, rec.Response.Body, time.Now().Add(ttl), key).Error
+}
+
+// Release implements Store.
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Exec("DELETE FROM idempotency_keys WHERE key = ?", key).Error
+}
+
+// cleanup deletes expired records, at most once per cleanupInterval.
+func (s *PostgresStore) cleanup(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < cleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	s.db.WithContext(ctx).Exec("DELETE FROM idempotency_keys WHERE expires_at < ?", time.Now())
+}
@@ -0,0 +1,97 @@
+// Package lifecycle starts the components of a server in order and stops
+// them in reverse order when it shuts down.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"leaderboard/internal/logging"
+	"time"
+)
+
+var logger = logging.Logger("lifecycle")
+
+// Component is a part of the server with a lifetime, such as a listener,
+// a background goroutine or a connection pool.
+type Component struct {
+	Name string
+	// Start starts the component without blocking. Nil if there is
+	// nothing to start, as for pools opened before the lifecycle runs.
+	Start func() error
+	// Stop stops the component, cutting short whatever is left when ctx
+	// is done. Nil if there is nothing to stop.
+	Stop func(ctx context.Context) error
+}
+
+// Lifecycle runs a list of components. Components can rely on those added
+// before them for as long as they run: they are started after them and
+// stopped before them.
+type Lifecycle struct {
+	components []Component
+	failed     chan error
+}
+
+func New() *Lifecycle {
+	return &Lifecycle{failed: make(chan error, 1)}
+}
+
+// Add appends a component.
+func (l *Lifecycle) Add(c Component) {
+	l.components = append(l.components, c)
+}
+
+// Fail reports that a running component stopped working, such as a server
+// that can no longer accept connections, which shuts the server down.
+func (l *Lifecycle) Fail(name string, err error) {
+	select {
+	case l.failed <- fmt.Errorf("%s: %w", name, err):
+	default:
+		// Already shutting down because of another failure.
+	}
+}
+
+// Run starts every component, then waits until ctx is done or a component
+// fails, and stops every started component within shutdownTimeout. The
+// returned error joins the failure, if any, and every error from stopping.
+func (l *Lifecycle) Run(ctx context.Context, shutdownTimeout time.Duration) error {
+	var failure error
+
+	started := 0
+	for _, c := range l.components {
+		if c.Start != nil {
+			if err := c.Start(); err != nil {
+				failure = fmt.Errorf("starting %s: %w", c.Name, err)
+				break
+			}
+		}
+		started++
+	}
+
+	if failure == nil {
+		select {
+		case <-ctx.Done():
+			logger.Info("Shutting down")
+		case failure = <-l.failed:
+			logger.Error("Shutting down after a failure", "error", failure)
+		}
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	errs := []error{failure}
+	for i := started - 1; i >= 0; i-- {
+		c := l.components[i]
+		if c.Stop == nil {
+			continue
+		}
+		if err := c.Stop(stopCtx); err != nil {
+			logger.Error("Failed to stop component", "name", c.Name, "error", err)
+			errs = append(errs, fmt.Errorf("stopping %s: %w", c.Name, err))
+			continue
+		}
+		logger.Info("Stopped component", "name", c.Name)
+	}
+	return errors.Join(errs...)
+}
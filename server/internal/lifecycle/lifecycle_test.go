@@ -0,0 +1,151 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// recorder adds components that record when they start and stop.
+type recorder struct {
+	l      *Lifecycle
+	events []string
+}
+
+// add adds a component that fails to start with startErr and to stop with
+// stopErr, when they are set.
+func (r *recorder) add(name string, startErr, stopErr error) {
+	r.l.Add(Component{
+		Name: name,
+		Start: func() error {
+			r.events = append(r.events, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return stopErr
+		},
+	})
+}
+
+func TestRun(t *testing.T) {
+	errStart := errors.New("port in use")
+	errStop := errors.New("flush failed")
+	errBroken := errors.New("listener closed")
+
+	tests := []struct {
+		name       string
+		setup      func(r *recorder)
+		fail       error
+		wantEvents []string
+		wantErrs   []error
+		wantMsg    string
+	}{
+		{
+			name: "reverse order",
+			setup: func(r *recorder) {
+				r.add("db", nil, nil)
+				r.add("cache", nil, nil)
+				r.add("http", nil, nil)
+			},
+			wantEvents: []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"},
+		},
+		{
+			name: "start failure stops those started",
+			setup: func(r *recorder) {
+				r.add("db", nil, nil)
+				r.add("http", errStart, nil)
+				r.add("grpc", nil, nil)
+			},
+			wantEvents: []string{"start db", "start http", "stop db"},
+			wantErrs:   []error{errStart},
+			wantMsg:    "starting http: port in use",
+		},
+		{
+			name: "stop errors are joined",
+			setup: func(r *recorder) {
+				r.add("db", nil, errStop)
+				r.add("cache", nil, nil)
+				r.add("http", nil, errStop)
+			},
+			wantEvents: []string{"start db", "start cache", "start http", "stop http", "stop cache", "stop db"},
+			wantErrs:   []error{errStop},
+			wantMsg:    "stopping http: flush failed\nstopping db: flush failed",
+		},
+		{
+			name: "failure and stop errors",
+			setup: func(r *recorder) {
+				r.add("db", nil, errStop)
+				r.add("http", nil, nil)
+			},
+			fail:       errBroken,
+			wantEvents: []string{"start db", "start http", "stop http", "stop db"},
+			wantErrs:   []error{errBroken, errStop},
+			wantMsg:    "http: listener closed\nstopping db: flush failed",
+		},
+		{
+			name: "components without start or stop",
+			setup: func(r *recorder) {
+				r.l.Add(Component{Name: "pool"})
+				r.add("http", nil, nil)
+			},
+			wantEvents: []string{"start http", "stop http"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{l: New()}
+			tt.setup(r)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.fail != nil {
+				r.l.Fail("http", tt.fail)
+				r.l.Fail("db", errors.New("ignored")) // only the first failure counts
+			} else {
+				cancel()
+			}
+			defer cancel()
+
+			err := r.l.Run(ctx, time.Second)
+			if !slices.Equal(r.events, tt.wantEvents) {
+				t.Errorf("events = %q, want %q", r.events, tt.wantEvents)
+			}
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Errorf("Run = %v, want nil", err)
+				}
+				return
+			}
+			for _, want := range tt.wantErrs {
+				if !errors.Is(err, want) {
+					t.Errorf("Run = %v, want it to wrap %v", err, want)
+				}
+			}
+			if err.Error() != tt.wantMsg {
+				t.Errorf("Run = %q, want %q", err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestRunStopDeadline(t *testing.T) {
+	l := New()
+	var deadline time.Time
+	l.Add(Component{Name: "http", Stop: func(ctx context.Context) error {
+		deadline, _ = ctx.Deadline()
+		return nil
+	}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	before := time.Now()
+	if err := l.Run(ctx, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if d := deadline.Sub(before); d < 59*time.Second || d > time.Minute+time.Second {
+		t.Errorf("components had %s to stop, want a minute", d)
+	}
+}
@@ -0,0 +1,129 @@
+// Package logging sets up log/slog: the format records are written in,
+// the level of each component, and the request ID of the context a record
+// is logged with.
+//
+// Packages log through a Logger named after their component, usually kept
+// in a package variable. Loggers can be created before Setup runs; they
+// write through whatever handler and levels are current when they log.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// Components are the names loggers are created with, whose level can be
+// set on its own.
+var Components = []string{
+	"access",
+	"audit",
+	"auth",
+	"config",
+	"database",
+	"events",
+	"grpc",
+	"http",
+	"lifecycle",
+	"ratelimit",
+	"repository",
+	"simulation",
+	"topwatcher",
+}
+
+// Levels are the least severe levels logged: Default for records without
+// a component or whose component has no level of its own.
+type Levels struct {
+	Default    slog.Level
+	Components map[string]slog.Level
+}
+
+func (l *Levels) of(component string) slog.Level {
+	if level, ok := l.Components[component]; ok {
+		return level
+	}
+	return l.Default
+}
+
+var (
+	// output is the handler records are finally written with.
+	output atomic.Pointer[slog.Handler]
+	levels atomic.Pointer[Levels]
+)
+
+func init() {
+	SetLevels(Levels{Default: slog.LevelInfo})
+	// Until Setup, records are written as text to stderr.
+	h := NewHandler(os.Stderr, "text")
+	output.Store(&h)
+}
+
+// NewHandler returns a handler writing to w in format, "json" for one JSON
+// object per record or "text" for key=value pairs. Levels are left to the
+// loggers, so it handles every record it is given.
+func NewHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: slog.Level(-100)}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Setup writes records with h from now on, adding request IDs, and makes a
+// Logger without a component the slog default, so slog's top-level
+// functions and the log package follow the default level.
+func Setup(h slog.Handler, l Levels) {
+	h = &contextHandler{Handler: h}
+	output.Store(&h)
+	SetLevels(l)
+	slog.SetDefault(Logger(""))
+}
+
+// SetLevels changes the levels of every logger.
+func SetLevels(l Levels) {
+	levels.Store(&l)
+}
+
+// Logger returns the logger of component, whose records carry it as the
+// "component" attribute.
+func Logger(component string) *slog.Logger {
+	return slog.New(&handler{component: component})
+}
+
+// handler filters records by the level of its component and passes them
+// to the output handler current when they are logged. Attributes and
+// groups are kept as steps replayed on that handler.
+type handler struct {
+	component string
+	steps     []func(slog.Handler) slog.Handler
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= levels.Load().of(h.component)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	out := *output.Load()
+	if h.component != "" {
+		out = out.WithAttrs([]slog.Attr{slog.String("component", h.component)})
+	}
+	for _, step := range h.steps {
+		out = step(out)
+	}
+	return out.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *handler) with(step func(slog.Handler) slog.Handler) *handler {
+	steps := append(h.steps[:len(h.steps):len(h.steps)], step)
+	return &handler{component: h.component, steps: steps}
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var leaderboardSizeDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "size"),
+	"Number of users on the leaderboard.",
+	nil, nil,
+)
+
+// sizeCollector reads the size of the leaderboard on every scrape.
+type sizeCollector struct {
+	size func(ctx context.Context) (int64, error)
+}
+
+// NewSizeCollector returns a collector reporting the leaderboard size
+// returned by size.
+func NewSizeCollector(size func(ctx context.Context) (int64, error)) prometheus.Collector {
+	return sizeCollector{size: size}
+}
+
+// Describe implements prometheus.Collector.
+func (c sizeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- leaderboardSizeDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c sizeCollector) Collect(ch chan<- prometheus.Metric) {
+	n, err := c.size(context.Background())
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(leaderboardSizeDesc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(leaderboardSizeDesc, prometheus.GaugeValue, float64(n))
+}
@@ -0,0 +1,115 @@
+// Package metrics defines the Prometheus metrics of the server and serves
+// them for scraping.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "leaderboard"
+
+// Registry holds every metric served by Handler. Metrics that depend on
+// components built at startup, such as connection pools, are registered
+// with it there.
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time to answer HTTP requests by method and route pattern.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	RedisCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Time to run Redis commands by command; pipelines count as one.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	RedisErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "errors_total",
+		Help:      "Failed Redis commands by command. Missing keys are not failures.",
+	}, []string{"command"})
+
+	// LeaderboardReads counts rank lookups by operation and by the backend
+	// that answered them, "redis" or "sql".
+	LeaderboardReads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reads_total",
+		Help:      "Leaderboard reads by operation and the backend that answered them.",
+	}, []string{"operation", "backend"})
+
+	RedisFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "redis",
+		Name:      "fallbacks_total",
+		Help:      "Reads answered from Postgres because Redis failed, by operation.",
+	}, []string{"operation"})
+
+	// CacheHits and CacheMisses count the leaderboard pages that the cache
+	// of the top of the leaderboard could and couldn't answer.
+	CacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "hits_total",
+		Help:      "Leaderboard pages answered from the cache of the top of the leaderboard.",
+	})
+
+	CacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "misses_total",
+		Help:      "Leaderboard pages within the cached top that had to be loaded.",
+	})
+
+	// SimulationUpdates counts the rating updates made by the simulation,
+	// by result, "ok" or "failed".
+	SimulationUpdates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "simulation",
+		Name:      "updates_total",
+		Help:      "Rating updates made by the simulation, by result.",
+	}, []string{"result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		RedisCommandDuration,
+		RedisErrors,
+		LeaderboardReads,
+		RedisFallbacks,
+		CacheHits,
+		CacheMisses,
+		SimulationUpdates,
+	)
+}
+
+// Handler serves the metrics in Registry. A metric that fails to be
+// collected is left out rather than failing the whole scrape.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		ErrorHandling: promhttp.ContinueOnError,
+		Registry:      Registry,
+	})
+}
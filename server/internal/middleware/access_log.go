@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"leaderboard/internal/logging"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+var (
+	logger    = logging.Logger("http")
+	accessLog = logging.Logger("access")
+)
+
+// AccessLog logs every request once answered, with its status, the bytes
+// of its body and how long it took. Like the Authorizer, the route is the
+// pattern the request matched. Records are logged with the request's
+// context, so they carry its request and trace IDs; failed requests are
+// logged as warnings (4xx) or errors (5xx).
+type AccessLog struct {
+	routes *http.ServeMux
+}
+
+func NewAccessLog(routes *http.ServeMux) *AccessLog {
+	return &AccessLog{routes: routes}
+}
+
+func (a *AccessLog) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		level := slog.LevelInfo
+		switch status := sw.code(); {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+		_, route := a.routes.Handler(r)
+		accessLog.LogAttrs(r.Context(), level, "Request served",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.String("route", route),
+			slog.Int("status", sw.code()),
+			slog.Int64("bytes", sw.bytes),
+			slog.Duration("duration", time.Since(start)),
+			slog.String("remote_addr", r.RemoteAddr),
+		)
+	})
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"leaderboard/internal/problem"
+	"leaderboard/internal/services"
+	"net/http"
+)
+
+// maxAuditedBody bounds the request bodies kept in the audit log; larger
+// ones are recorded without their body.
+const maxAuditedBody = 16 << 10
+
+// Audit records every call of the routes it is applied to in the audit
+// log once answered, failed ones included: the caller, the route, the
+// status, and the path, query and JSON body of the request. Responses are
+// not recorded, since some carry secrets such as issued API keys. It must
+// run after the Authorizer, which identifies the caller. Like the
+// Authorizer, routes are identified by the pattern they were registered
+// with.
+type Audit struct {
+	audit  *services.AuditService
+	routes *http.ServeMux
+	apply  map[string]bool
+}
+
+func NewAudit(audit *services.AuditService, routes *http.ServeMux) *Audit {
+	return &Audit{audit: audit, routes: routes, apply: make(map[string]bool)}
+}
+
+// Apply records calls of the given route patterns.
+func (a *Audit) Apply(patterns ...string) {
+	for _, pattern := range patterns {
+		a.apply[pattern] = true
+	}
+}
+
+type auditDetails struct {
+	Path  string          `json:"path"`
+	Query string          `json:"query,omitempty"`
+	Body  json.RawMessage `json:"body,omitempty"`
+}
+
+func (a *Audit) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := a.routes.Handler(r)
+		if !a.apply[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxAuditedBody+1))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		d := auditDetails{Path: r.URL.Path, Query: r.URL.RawQuery}
+		if len(body) <= maxAuditedBody && json.Valid(body) {
+			d.Body = body
+		}
+		details, _ := json.Marshal(d)
+		// The action is done even if the client has gone.
+		ctx := context.WithoutCancel(r.Context())
+		if err := a.audit.Record(ctx, pattern, sw.code(), details); err != nil {
+			logger.ErrorContext(ctx, "Failed to record audit entry", "action", pattern, "error", err)
+		}
+	})
+}
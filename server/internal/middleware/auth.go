@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"errors"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/problem"
+	"leaderboard/internal/services"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the API key of server-to-server clients.
+const APIKeyHeader = "X-API-Key"
+
+// Authorizer enforces the role required by each route. Routes are
+// identified by the pattern they were registered with on the ServeMux, so
+// the policy reads like the route table in main. On public routes, callers
+// presenting credentials are still identified, so they are rate limited as
+// themselves rather than by IP address.
+type Authorizer struct {
+	apiKeys  *services.APIKeyService
+	tokens   *auth.TokenVerifier
+	routes   *http.ServeMux
+	required map[string]auth.Role
+	players  map[string]bool
+}
+
+// NewAuthorizer returns an Authorizer checking API keys against apiKeys.
+// tokens verifies player tokens and may be nil when they are disabled.
+func NewAuthorizer(apiKeys *services.APIKeyService, tokens *auth.TokenVerifier, routes *http.ServeMux) *Authorizer {
+	return &Authorizer{
+		apiKeys:  apiKeys,
+		tokens:   tokens,
+		routes:   routes,
+		required: make(map[string]auth.Role),
+		players:  make(map[string]bool),
+	}
+}
+
+// Require makes the given route patterns require a key with at least role.
+// Routes without a requirement are public.
+func (a *Authorizer) Require(role auth.Role, patterns ...string) {
+	for _, pattern := range patterns {
+		a.required[pattern] = role
+	}
+}
+
+// AllowPlayers lets a player token stand in for the API key on the given
+// route patterns. Handlers are responsible for limiting players to their
+// own user.
+func (a *Authorizer) AllowPlayers(patterns ...string) {
+	for _, pattern := range patterns {
+		a.players[pattern] = true
+	}
+}
+
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := a.routes.Handler(r)
+		role, ok := a.required[pattern]
+		if !ok {
+			a.identify(w, r, next)
+			return
+		}
+
+		playersAllowed := a.tokens != nil && a.players[pattern]
+
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			token, hasToken := bearerToken(r)
+			if !playersAllowed {
+				a.unauthorized(w, r, false, "missing_credentials", "Missing API key")
+				return
+			}
+			if !hasToken {
+				a.unauthorized(w, r, true, "missing_credentials", "Missing API key or player token")
+				return
+			}
+
+			principal, err := a.tokens.Verify(token)
+			if err != nil {
+				a.unauthorized(w, r, playersAllowed, "invalid_token", "Invalid player token")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+			return
+		}
+
+		principal, err := a.apiKeys.Authenticate(r.Context(), key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			a.unauthorized(w, r, playersAllowed, "invalid_api_key", "Invalid API key")
+			return
+		}
+		if err != nil {
+			logger.ErrorContext(r.Context(), "API key lookup failed", "error", err)
+			problem.Write(w, r, http.StatusServiceUnavailable, "auth_unavailable", "Failed to authenticate")
+			return
+		}
+
+		if !principal.Role.Allows(role) {
+			problem.Write(w, r, http.StatusForbidden, "insufficient_role", "API key lacks the "+string(role)+" role")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
+
+// identify serves a public route, as the caller when valid credentials are
+// presented and anonymously otherwise. Invalid credentials are refused as
+// they are on other routes, but a failed lookup doesn't fail the request.
+func (a *Authorizer) identify(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	var principal *auth.Principal
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		p, err := a.apiKeys.Authenticate(r.Context(), key)
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			a.unauthorized(w, r, false, "invalid_api_key", "Invalid API key")
+			return
+		}
+		if err != nil {
+			logger.WarnContext(r.Context(), "API key lookup failed, serving anonymously", "error", err)
+		}
+		principal = p
+	} else if token, ok := bearerToken(r); ok && a.tokens != nil {
+		p, err := a.tokens.Verify(token)
+		if err != nil {
+			a.unauthorized(w, r, true, "invalid_token", "Invalid player token")
+			return
+		}
+		principal = p
+	}
+
+	if principal != nil {
+		r = r.WithContext(auth.NewContext(r.Context(), principal))
+	}
+	next.ServeHTTP(w, r)
+}
+
+// hasCredentials reports whether r presents an API key or player token.
+func hasCredentials(r *http.Request) bool {
+	_, hasToken := bearerToken(r)
+	return hasToken || r.Header.Get(APIKeyHeader) != ""
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func (a *Authorizer) unauthorized(w http.ResponseWriter, r *http.Request, playersAllowed bool, code, msg string) {
+	w.Header().Add("WWW-Authenticate", `APIKey realm="leaderboard"`)
+	if playersAllowed {
+		w.Header().Add("WWW-Authenticate", `Bearer realm="leaderboard"`)
+	}
+	problem.Write(w, r, http.StatusUnauthorized, code, msg)
+}
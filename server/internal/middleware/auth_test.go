@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/models"
+	"leaderboard/internal/ratelimit"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret    = "test-secret"
+	testBootstrap = "bootstrap-key"
+	// testAPIKey is accepted by stubAPIKeys as key 3.
+	testAPIKey = "lb_valid"
+)
+
+// stubAPIKeys knows a single reader key, or fails every lookup with err.
+type stubAPIKeys struct {
+	repository.APIKeyRepository
+	err error
+}
+
+func (s *stubAPIKeys) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	now := time.Now()
+	return &models.APIKey{ID: 3, Name: "scores", Role: string(auth.RoleReader), LastUsedAt: &now}, nil
+}
+
+func playerToken(t *testing.T, subject string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   subject,
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// newAuthServer serves a public GET /leaderboard and a GET /me requiring a
+// reader key or player token, each answering with the client key of the
+// request.
+func newAuthServer(t *testing.T, apiKeys repository.APIKeyRepository) http.Handler {
+	t.Helper()
+	tokens, err := auth.NewTokenVerifier(auth.TokenConfig{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	echo := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(clientKey(r, false))) }
+	mux.HandleFunc("GET /leaderboard", echo)
+	mux.HandleFunc("GET /me", echo)
+
+	a := NewAuthorizer(services.NewAPIKeyService(apiKeys, testBootstrap), tokens, mux)
+	a.Require(auth.RoleReader, "GET /me")
+	a.AllowPlayers("GET /me")
+	return a.Middleware(mux)
+}
+
+func TestAuthorizer(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		apiKey  string
+		token   string
+		keysErr error
+		want    int
+		wantKey string
+	}{
+		{name: "public anonymous", path: "/leaderboard", want: 200, wantKey: "ip:192.0.2.1"},
+		{name: "public api key", path: "/leaderboard", apiKey: testAPIKey, want: 200, wantKey: "key:3"},
+		{name: "public bootstrap key", path: "/leaderboard", apiKey: testBootstrap, want: 200, wantKey: "key:bootstrap"},
+		{name: "public player", path: "/leaderboard", token: "9", want: 200, wantKey: "user:9"},
+		{name: "public invalid key", path: "/leaderboard", apiKey: "wrong", want: 401},
+		{name: "public invalid token", path: "/leaderboard", token: "x", want: 401},
+		{name: "public lookup unavailable", path: "/leaderboard", apiKey: testAPIKey, keysErr: repository.ErrDatabaseUnavailable, want: 200, wantKey: "ip:192.0.2.1"},
+		{name: "required anonymous", path: "/me", want: 401},
+		{name: "required api key", path: "/me", apiKey: testAPIKey, want: 200, wantKey: "key:3"},
+		{name: "required player", path: "/me", token: "9", want: 200, wantKey: "user:9"},
+		{name: "required invalid key", path: "/me", apiKey: "wrong", want: 401},
+		{name: "required lookup unavailable", path: "/me", apiKey: testAPIKey, keysErr: repository.ErrDatabaseUnavailable, want: 503},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newAuthServer(t, &stubAPIKeys{err: tt.keysErr})
+			r := httptest.NewRequest("GET", tt.path, nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			if tt.apiKey != "" {
+				r.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			switch tt.token {
+			case "":
+			case "x":
+				r.Header.Set("Authorization", "Bearer not-a-token")
+			default:
+				r.Header.Set("Authorization", "Bearer "+playerToken(t, tt.token))
+			}
+
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.wantKey != "" && w.Body.String() != tt.wantKey {
+				t.Errorf("served as %q, want %q", w.Body, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestRateLimiterCredentials(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaderboard", func(w http.ResponseWriter, r *http.Request) {})
+	l := NewRateLimiter(ratelimit.NewMemoryLimiter(), mux, false)
+	l.LimitCredentials("auth", ratelimit.Limit{Rate: 0.001, Burst: 1})
+	h := l.Middleware(mux)
+
+	get := func(apiKey string) int {
+		r := httptest.NewRequest("GET", "/leaderboard", nil)
+		r.RemoteAddr = "192.0.2.1:4321"
+		if apiKey != "" {
+			r.Header.Set(APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Anonymous requests don't count as attempts.
+	for range 3 {
+		if got := get(""); got != http.StatusOK {
+			t.Fatalf("anonymous request: status %d, want 200", got)
+		}
+	}
+	// Attempts are counted per IP address, whatever the key.
+	if got := get("first"); got != http.StatusOK {
+		t.Errorf("first attempt: status %d, want 200", got)
+	}
+	if got := get("second"); got != http.StatusTooManyRequests {
+		t.Errorf("second attempt: status %d, want 429", got)
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"leaderboard/internal/auth"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// clientKey identifies the caller of a request: the API key or player when
+// authenticated, the IP address otherwise. With trustProxy the address is
+// taken from X-Forwarded-For instead of the connection.
+func clientKey(r *http.Request, trustProxy bool) string {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return p.ClientKey()
+	}
+	return "ip:" + clientIP(r, trustProxy)
+}
+
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		// The proxy in front of us appends the address it saw, so the last
+		// entry is the only one a client can't forge.
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			parts := strings.Split(fwd, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
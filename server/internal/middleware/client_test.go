@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"leaderboard/internal/auth"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *auth.Principal
+		forwarded  string
+		trustProxy bool
+		want       string
+	}{
+		{name: "api key", principal: &auth.Principal{KeyID: 3, Name: "scores"}, want: "key:3"},
+		{name: "bootstrap key", principal: &auth.Principal{Name: "bootstrap"}, want: "key:bootstrap"},
+		{name: "player", principal: &auth.Principal{Name: "user:9", UserID: 9}, want: "user:9"},
+		{name: "anonymous", want: "ip:192.0.2.1"},
+		{name: "untrusted proxy", forwarded: "198.51.100.7", want: "ip:192.0.2.1"},
+		{name: "trusted proxy", forwarded: "203.0.113.5, 198.51.100.7", trustProxy: true, want: "ip:198.51.100.7"},
+		{name: "trusted proxy without header", trustProxy: true, want: "ip:192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/leaderboard", nil)
+			r.RemoteAddr = "192.0.2.1:4321"
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.principal != nil {
+				r = r.WithContext(auth.NewContext(r.Context(), tt.principal))
+			}
+
+			if got := clientKey(r, tt.trustProxy); got != tt.want {
+				t.Errorf("clientKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
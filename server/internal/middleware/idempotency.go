@@ -0,0 +1,211 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"leaderboard/internal/idempotency"
+	"leaderboard/internal/problem"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a write without applying it
+	// twice.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses replayed from the store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBody bounds the request bodies read to fingerprint them.
+	maxIdempotentBody = 1 << 20
+	// maxIdempotentDuration bounds requests with an Idempotency-Key that
+	// have no deadline of their own, so their key can be reserved for as
+	// long as they run.
+	maxIdempotentDuration = time.Minute
+	// idempotencyStoreMargin is how long a key stays reserved past the
+	// deadline of its request, and bounds storing the response then.
+	idempotencyStoreMargin = 5 * time.Second
+)
+
+// Idempotency stores the response of requests carrying an Idempotency-Key
+// header and replays it for retries with the same key, so a retried write
+// is applied once. Keys are scoped to the client, so it must run after the
+// Authorizer. Like the Authorizer, routes are identified by the pattern
+// they were registered with.
+type Idempotency struct {
+	store  idempotency.Store
+	routes *http.ServeMux
+	// ttl is a time.Duration, changed by SetTTL.
+	ttl   atomic.Int64
+	apply map[string]bool
+}
+
+func NewIdempotency(store idempotency.Store, routes *http.ServeMux, ttl time.Duration) *Idempotency {
+	m := &Idempotency{store: store, routes: routes, apply: make(map[string]bool)}
+	m.ttl.Store(int64(ttl))
+	return m
+}
+
+// SetTTL changes how long responses stored from now on are replayed for.
+func (m *Idempotency) SetTTL(ttl time.Duration) {
+	m.ttl.Store(int64(ttl))
+}
+
+// Apply honours Idempotency-Key on the given route patterns.
+func (m *Idempotency) Apply(patterns ...string) {
+	for _, pattern := range patterns {
+		m.apply[pattern] = true
+	}
+}
+
+func (m *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, pattern := m.routes.Handler(r); !m.apply[pattern] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem.Write(w, r, http.StatusBadRequest, "idempotency_key_invalid", "Idempotency-Key is too long")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxIdempotentBody+1))
+		if err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "invalid_body", "Failed to read request body")
+			return
+		}
+		if len(body) > maxIdempotentBody {
+			problem.Write(w, r, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The routes this applies to are authenticated, so the IP address
+		// is only a fallback and proxies don't need to be trusted.
+		storeKey := hashParts(clientKey(r, false), key)
+		fingerprint := hashParts(r.Method, r.URL.Path, r.URL.RawQuery, string(body))
+
+		// A retry must not run while the request holding the key still can,
+		// so the key stays reserved until the request's deadline has passed.
+		ctx := r.Context()
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, maxIdempotentDuration)
+			defer cancel()
+			r = r.WithContext(ctx)
+		}
+		deadline, _ := ctx.Deadline()
+
+		rec, err := m.store.Reserve(ctx, storeKey, fingerprint, time.Until(deadline)+idempotencyStoreMargin)
+		if err != nil {
+			logger.ErrorContext(r.Context(), "Idempotency store unavailable", "error", err)
+			problem.Write(w, r, http.StatusServiceUnavailable, "idempotency_unavailable", "Failed to check Idempotency-Key")
+			return
+		}
+
+		if rec != nil {
+			switch {
+			case rec.Fingerprint != fingerprint:
+				problem.Write(w, r, http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+			case rec.Response == nil:
+				w.Header().Set("Retry-After", "1")
+				problem.Write(w, r, http.StatusConflict, "idempotency_key_in_progress", "A request with this Idempotency-Key is in progress")
+			default:
+				replay(w, rec.Response)
+			}
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w, before: headerNames(w.Header())}
+		defer func() {
+			// The request may have been canceled or run out of time; its
+			// key must be released or completed all the same.
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), idempotencyStoreMargin)
+			defer cancel()
+
+			// Server errors are not stored so the client can retry them.
+			if rw.status == 0 || rw.status >= 500 {
+				if err := m.store.Release(ctx, storeKey); err != nil {
+					logger.ErrorContext(r.Context(), "Failed to release Idempotency-Key", "error", err)
+				}
+				return
+			}
+
+			resp := &idempotency.Response{Status: rw.status, Header: rw.header, Body: rw.body.Bytes()}
+			err := m.store.Complete(ctx, storeKey, idempotency.Record{Fingerprint: fingerprint, Response: resp}, time.Duration(m.ttl.Load()))
+			if err != nil {
+				logger.ErrorContext(r.Context(), "Failed to store idempotent response", "error", err)
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func replay(w http.ResponseWriter, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+func hashParts(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func headerNames(h http.Header) map[string]bool {
+	names := make(map[string]bool, len(h))
+	for name := range h {
+		names[name] = true
+	}
+	return names
+}
+
+// recordingWriter passes a response through while keeping a copy of it.
+// Only headers set by the handler are kept; those set by outer middleware,
+// such as CORS or rate limit headers, are set again on replay.
+type recordingWriter struct {
+	http.ResponseWriter
+	before map[string]bool
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+		w.header = make(http.Header)
+		for name, values := range w.ResponseWriter.Header() {
+			if !w.before[name] {
+				w.header[name] = values
+			}
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
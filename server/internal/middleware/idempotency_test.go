@@ -0,0 +1,281 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"leaderboard/internal/idempotency"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryStore is an idempotency.Store keeping records in a map. It records
+// the reservation TTLs it was given and whether the contexts of Complete
+// and Release were already done.
+type memoryStore struct {
+	mu          sync.Mutex
+	records     map[string]idempotency.Record
+	reserveErr  error
+	pendingTTLs []time.Duration
+	doneCtx     bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]idempotency.Record)}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, key, fingerprint string, pendingTTL time.Duration) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reserveErr != nil {
+		return nil, s.reserveErr
+	}
+	s.pendingTTLs = append(s.pendingTTLs, pendingTTL)
+	if rec, ok := s.records[key]; ok {
+		return &rec, nil
+	}
+	s.records[key] = idempotency.Record{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key string, rec idempotency.Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doneCtx = s.doneCtx || ctx.Err() != nil
+	s.records[key] = rec
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doneCtx = s.doneCtx || ctx.Err() != nil
+	delete(s.records, key)
+	return nil
+}
+
+// idempotencyServer serves POST /users, counting its calls, behind the
+// Idempotency middleware. status is the status the handler answers with.
+type idempotencyServer struct {
+	handler http.Handler
+	store   *memoryStore
+	calls   int
+	status  int
+	// cancel, when set, is called by the handler before it answers.
+	cancel context.CancelFunc
+}
+
+func newIdempotencyServer() *idempotencyServer {
+	s := &idempotencyServer{store: newMemoryStore(), status: http.StatusCreated}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+		if s.cancel != nil {
+			s.cancel()
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Location", "/users/1")
+		w.WriteHeader(s.status)
+		w.Write(body)
+	})
+	mux.HandleFunc("POST /other", func(w http.ResponseWriter, r *http.Request) {
+		s.calls++
+	})
+
+	m := NewIdempotency(s.store, mux, time.Hour)
+	m.Apply("POST /users")
+	s.handler = m.Middleware(mux)
+	return s
+}
+
+func (s *idempotencyServer) post(ctx context.Context, path, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequestWithContext(ctx, "POST", path, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotency(t *testing.T) {
+	type request struct {
+		path, key, body string
+	}
+	tests := []struct {
+		name     string
+		setup    func(s *idempotencyServer)
+		requests []request
+		// want are the statuses of the responses, and wantCalls how often
+		// the handler ran.
+		want       []int
+		wantCalls  int
+		wantReplay bool
+	}{
+		{
+			name:      "without key",
+			requests:  []request{{"/users", "", "a"}, {"/users", "", "a"}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "route without idempotency",
+			requests:  []request{{"/other", "k", "a"}, {"/other", "k", "a"}},
+			want:      []int{http.StatusOK, http.StatusOK},
+			wantCalls: 2,
+		},
+		{
+			name:       "replay",
+			requests:   []request{{"/users", "k", "a"}, {"/users", "k", "a"}},
+			want:       []int{http.StatusCreated, http.StatusCreated},
+			wantCalls:  1,
+			wantReplay: true,
+		},
+		{
+			name:      "keys are separate",
+			requests:  []request{{"/users", "k1", "a"}, {"/users", "k2", "a"}},
+			want:      []int{http.StatusCreated, http.StatusCreated},
+			wantCalls: 2,
+		},
+		{
+			name:      "key reused for another request",
+			requests:  []request{{"/users", "k", "a"}, {"/users", "k", "b"}},
+			want:      []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			wantCalls: 1,
+		},
+		{
+			name:       "client errors are replayed",
+			setup:      func(s *idempotencyServer) { s.status = http.StatusBadRequest },
+			requests:   []request{{"/users", "k", "a"}, {"/users", "k", "a"}},
+			want:       []int{http.StatusBadRequest, http.StatusBadRequest},
+			wantCalls:  1,
+			wantReplay: true,
+		},
+		{
+			name:      "server errors release the key",
+			setup:     func(s *idempotencyServer) { s.status = http.StatusInternalServerError },
+			requests:  []request{{"/users", "k", "a"}, {"/users", "k", "a"}},
+			want:      []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			wantCalls: 2,
+		},
+		{
+			name:      "key too long",
+			requests:  []request{{"/users", strings.Repeat("k", maxIdempotencyKeyLength+1), "a"}},
+			want:      []int{http.StatusBadRequest},
+			wantCalls: 0,
+		},
+		{
+			name:      "store unavailable",
+			setup:     func(s *idempotencyServer) { s.store.reserveErr = errors.New("store down") },
+			requests:  []request{{"/users", "k", "a"}},
+			want:      []int{http.StatusServiceUnavailable},
+			wantCalls: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newIdempotencyServer()
+			if tt.setup != nil {
+				tt.setup(s)
+			}
+
+			var first, last *httptest.ResponseRecorder
+			for i, req := range tt.requests {
+				w := s.post(context.Background(), req.path, req.key, req.body)
+				if w.Code != tt.want[i] {
+					t.Errorf("request %d: status %d, want %d", i, w.Code, tt.want[i])
+				}
+				if first == nil {
+					first = w
+				}
+				last = w
+			}
+
+			if s.calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", s.calls, tt.wantCalls)
+			}
+			replayed := last.Header().Get(IdempotentReplayedHeader) == "true"
+			if replayed != tt.wantReplay {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplay)
+			}
+			if replayed {
+				if last.Body.String() != first.Body.String() || last.Header().Get("Location") != first.Header().Get("Location") {
+					t.Errorf("replayed %q with Location %q, want %q with Location %q",
+						last.Body, last.Header().Get("Location"), first.Body, first.Header().Get("Location"))
+				}
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	s := newIdempotencyServer()
+
+	// Hold the key as a request still being served would.
+	rec, err := s.store.Reserve(context.Background(), hashParts("ip:192.0.2.1", "k"), hashParts("POST", "/users", "", "a"), time.Minute)
+	if err != nil || rec != nil {
+		t.Fatalf("Reserve = %v, %v", rec, err)
+	}
+
+	w := s.post(context.Background(), "/users", "k", "a")
+	if w.Code != http.StatusConflict || w.Header().Get("Retry-After") == "" {
+		t.Errorf("status %d with Retry-After %q, want 409 with Retry-After", w.Code, w.Header().Get("Retry-After"))
+	}
+	if s.calls != 0 {
+		t.Errorf("handler ran %d times, want 0", s.calls)
+	}
+}
+
+func TestIdempotencyCanceledRequest(t *testing.T) {
+	for _, status := range []int{http.StatusCreated, http.StatusInternalServerError} {
+		s := newIdempotencyServer()
+		s.status = status
+		ctx, cancel := context.WithCancel(context.Background())
+		s.cancel = cancel
+
+		s.post(ctx, "/users", "k", "a")
+		if s.store.doneCtx {
+			t.Errorf("status %d: the key was stored with the canceled request context", status)
+		}
+		_, stored := s.store.records[hashParts("ip:192.0.2.1", "k")]
+		if want := status < 500; stored != want {
+			t.Errorf("status %d: stored = %v, want %v", status, stored, want)
+		}
+	}
+}
+
+func TestIdempotencyPendingTTL(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration
+		want     time.Duration
+	}{
+		{name: "no deadline", want: maxIdempotentDuration + idempotencyStoreMargin},
+		{name: "request deadline", deadline: 10 * time.Second, want: 10*time.Second + idempotencyStoreMargin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newIdempotencyServer()
+			ctx := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.deadline)
+				defer cancel()
+			}
+
+			s.post(ctx, "/users", "k", "a")
+			if len(s.store.pendingTTLs) != 1 {
+				t.Fatalf("%d reservations, want 1", len(s.store.pendingTTLs))
+			}
+			if got := s.store.pendingTTLs[0]; got > tt.want || got < tt.want-time.Second {
+				t.Errorf("key reserved for %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
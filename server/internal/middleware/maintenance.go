@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"leaderboard/internal/problem"
+	"leaderboard/internal/services"
+	"net/http"
+)
+
+// MaintenanceMode turns requests to the routes it is applied to away with
+// a 503 while maintenance mode is on. Like the Authorizer, routes are
+// identified by the pattern they were registered with.
+type MaintenanceMode struct {
+	maintenance *services.Maintenance
+	routes      *http.ServeMux
+	apply       map[string]bool
+}
+
+func NewMaintenanceMode(maintenance *services.Maintenance, routes *http.ServeMux) *MaintenanceMode {
+	return &MaintenanceMode{maintenance: maintenance, routes: routes, apply: make(map[string]bool)}
+}
+
+// Apply turns requests to the given route patterns away during
+// maintenance.
+func (m *MaintenanceMode) Apply(patterns ...string) {
+	for _, pattern := range patterns {
+		m.apply[pattern] = true
+	}
+}
+
+func (m *MaintenanceMode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := m.routes.Handler(r); m.apply[pattern] {
+			// Not problem.Error, which would log every refusal.
+			if status := m.maintenance.Status(); status.Enabled {
+				problem.Write(w, r, http.StatusServiceUnavailable, "maintenance", status.Message)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
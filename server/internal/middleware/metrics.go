@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"leaderboard/internal/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Metrics counts requests and measures their latency. Like the Authorizer,
+// routes are identified by the pattern they were registered with, so
+// /v1/users/1 and /v1/users/2 share the series of "GET /v1/users/{id}".
+// Requests matching no route are recorded as "unmatched".
+type Metrics struct {
+	routes *http.ServeMux
+}
+
+func NewMetrics(routes *http.ServeMux) *Metrics {
+	return &Metrics{routes: routes}
+}
+
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := m.routes.Handler(r)
+		if route == "" {
+			route = "unmatched"
+		}
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r)
+
+		metrics.HTTPRequestDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+		metrics.HTTPRequests.WithLabelValues(r.Method, route, strconv.Itoa(sw.code())).Inc()
+	})
+}
+
+// statusWriter remembers the status code of a response and counts the
+// bytes of its body.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// code returns the status sent, 200 if the handler wrote nothing.
+func (w *statusWriter) code() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
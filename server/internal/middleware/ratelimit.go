@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"leaderboard/internal/problem"
+	"leaderboard/internal/ratelimit"
+	"maps"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimiter limits how often each client may call groups of routes.
+// Authenticated clients are counted by API key or player, everyone else by
+// IP address. Like the Authorizer, routes are identified by the pattern
+// they were registered with, and it must run after the Authorizer to see
+// the caller; in front of it, every client is counted by IP address.
+// Limits can be changed while serving.
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	routes  *http.ServeMux
+	// groups maps route patterns to the name of their group.
+	groups map[string]string
+	// credentials is the group of requests presenting credentials on
+	// routes without a group of their own, if any.
+	credentials string
+	// limits maps group names to their limit. It is replaced as a whole
+	// when limits change.
+	limits atomic.Pointer[map[string]ratelimit.Limit]
+	// trustProxy takes the client IP from X-Forwarded-For instead of the
+	// connection, for deployments behind a reverse proxy.
+	trustProxy atomic.Bool
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, routes *http.ServeMux, trustProxy bool) *RateLimiter {
+	l := &RateLimiter{
+		limiter: limiter,
+		routes:  routes,
+		groups:  make(map[string]string),
+	}
+	l.limits.Store(&map[string]ratelimit.Limit{})
+	l.trustProxy.Store(trustProxy)
+	return l
+}
+
+// Limit applies limit to the given route patterns. Routes of the same group
+// share one bucket per client. A disabled limit leaves the routes
+// unlimited until SetLimits enables it.
+func (l *RateLimiter) Limit(group string, limit ratelimit.Limit, patterns ...string) {
+	for _, pattern := range patterns {
+		l.groups[pattern] = group
+	}
+	l.SetLimits(map[string]ratelimit.Limit{group: limit})
+}
+
+// LimitCredentials applies limit to every request presenting an API key or
+// player token on a route without a group of its own. Each one costs a
+// lookup, so in front of the Authorizer this limits attempts to guess
+// credentials per IP address.
+func (l *RateLimiter) LimitCredentials(group string, limit ratelimit.Limit) {
+	l.credentials = group
+	l.SetLimits(map[string]ratelimit.Limit{group: limit})
+}
+
+// SetLimits changes the limits of the given groups at once. Buckets keep
+// their tokens, so clients only see the new limit as they refill.
+func (l *RateLimiter) SetLimits(limits map[string]ratelimit.Limit) {
+	next := maps.Clone(*l.limits.Load())
+	maps.Copy(next, limits)
+	l.limits.Store(&next)
+}
+
+// SetTrustProxy changes whether X-Forwarded-For identifies clients.
+func (l *RateLimiter) SetTrustProxy(trustProxy bool) {
+	l.trustProxy.Store(trustProxy)
+}
+
+func (l *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pattern := l.routes.Handler(r)
+		group, ok := l.groups[pattern]
+		if !ok && l.credentials != "" && hasCredentials(r) {
+			group, ok = l.credentials, true
+		}
+		limit := (*l.limits.Load())[group]
+		if !ok || !limit.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		res, err := l.limiter.Allow(r.Context(), group+":"+clientKey(r, l.trustProxy.Load()), limit)
+		if err != nil {
+			// Rather serve the request than fail it over bookkeeping.
+			logger.ErrorContext(r.Context(), "Rate limiter failed", "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Policy", strconv.Itoa(limit.Burst)+";w="+strconv.Itoa(ceilSeconds(limit.Window())))
+		h.Set("RateLimit-Limit", strconv.Itoa(limit.Burst))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+			problem.Write(w, r, http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"leaderboard/internal/logging"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the ID of a request, chosen by the client or
+// the proxy in front of the server, or else generated.
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds the IDs taken from clients, which end up in
+// every log line of their request.
+const maxRequestIDLength = 128
+
+// RequestID gives every request an ID, taken from its RequestIDHeader when
+// it is a reasonable one, and puts it in the request's context, so every
+// record logged while serving it carries it, and in the response. The ID
+// is also recorded on the request's span.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request.id", id))
+		next.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts IDs of letters, digits and the punctuation of
+// UUIDs and similar formats, so they can't forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never fails.
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
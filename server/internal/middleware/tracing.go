@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"leaderboard/internal/tracing"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDHeader carries the ID of the trace a response belongs to, for
+// quoting in bug reports.
+const TraceIDHeader = "X-Trace-Id"
+
+// Tracing starts a span for every request, continuing the trace of the
+// caller's traceparent header if any. Like the Authorizer, routes are
+// identified by the pattern they were registered with, which names the
+// span. The trace ID is returned in TraceIDHeader.
+type Tracing struct {
+	routes *http.ServeMux
+}
+
+func NewTracing(routes *http.ServeMux) *Tracing {
+	return &Tracing{routes: routes}
+}
+
+func (t *Tracing) Middleware(next http.Handler) http.Handler {
+	traced := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := t.routes.Handler(r); pattern != "" {
+			// Patterns start with the method; the route is the path.
+			_, route, _ := strings.Cut(pattern, " ")
+			trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(route))
+		}
+		if id := tracing.TraceID(r.Context()); id != "" {
+			w.Header().Set(TraceIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	return otelhttp.NewHandler(traced, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if _, pattern := t.routes.Handler(r); pattern != "" {
+				return pattern
+			}
+			return r.Method
+		}),
+	)
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// APIKey is a credential for server-to-server clients. Only a hash of the
+// key is stored; Prefix is kept so keys can be told apart in listings.
+type APIKey struct {
+	ID         int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Role       string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// AuditEntry records an operations action: who did what, and how it went.
+type AuditEntry struct {
+	ID        int64
+	CreatedAt time.Time
+	// Actor is the name of the API key, or of the bootstrap key, used.
+	Actor string
+	// KeyID is the ID of that key, nil for the bootstrap key.
+	KeyID *int
+	// Action is the route called, such as "POST /admin/simulation/start".
+	Action string
+	// Status is the HTTP status of the response.
+	Status    int
+	RequestID string
+	// Details is the JSON body of the request, if any.
+	Details []byte
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
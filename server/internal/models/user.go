@@ -5,7 +5,13 @@ import "time"
 type User struct {
 	ID       int
 	Username string
-	Rating   int
+	// UsernameNormalized is the canonical form of Username, unique across
+	// users so names differing only in case or lookalike characters can't
+	// both be registered.
+	UsernameNormalized string `json:"-"`
+	Rating             int
+	// Version is incremented on every update, for optimistic concurrency.
+	Version int `gorm:"default:1"`
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
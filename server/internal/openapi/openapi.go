@@ -0,0 +1,128 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"leaderboard/internal/problem"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+)
+
+//go:embed openapi.yaml
+var specYAML []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+// Spec is the parsed OpenAPI document of the HTTP API.
+type Spec struct {
+	doc    *openapi3.T
+	json   []byte
+	router routers.Router
+}
+
+// Load parses and validates the embedded OpenAPI document.
+func Load() (*Spec, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(specYAML)
+	if err != nil {
+		return nil, err
+	}
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, err
+	}
+
+	specJSON, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Spec{doc: doc, json: specJSON, router: router}, nil
+}
+
+// ServeSpec serves the document as JSON.
+func (s *Spec) ServeSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(s.json)
+}
+
+// ServeDocs serves a Swagger UI page rendering the document.
+func (s *Spec) ServeDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(docsHTML)
+}
+
+// ValidateRequests rejects requests that don't match the document with a
+// 400 describing the first problem found, before they reach a handler.
+// Requests for paths the document doesn't describe are passed through so
+// the mux can answer them.
+func (s *Spec) ValidateRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := s.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         false,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			problem.Write(w, r, http.StatusBadRequest, "invalid_request", describe(err))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// describe turns a validation error into a short message that names the
+// offending parameter or body field.
+func describe(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+
+	where := "request body"
+	if requestErr.Parameter != nil {
+		where = requestErr.Parameter.In + " parameter " + requestErr.Parameter.Name
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		if field := strings.Join(schemaErr.JSONPointer(), "."); requestErr.Parameter == nil && field != "" {
+			where = "request body field " + field
+		}
+		return where + ": " + schemaErr.Reason
+	}
+
+	reason := requestErr.Reason
+	if requestErr.Err != nil {
+		if inner := requestErr.Err.Error(); reason == "" || reason == inner {
+			reason = inner
+		} else {
+			reason += ": " + inner
+		}
+	}
+	return where + ": " + reason
+}
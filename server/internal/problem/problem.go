@@ -0,0 +1,105 @@
+// Package problem writes error responses as RFC 7807 problem details, so
+// every failure of the HTTP API has the same shape and a machine-readable
+// code.
+package problem
+
+import (
+	"encoding/json"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/logging"
+	"net/http"
+)
+
+var logger = logging.Logger("http")
+
+const ContentType = "application/problem+json"
+
+// StatusClientClosedRequest is the nonstandard status, borrowed from nginx,
+// of requests the client gave up on before they were answered. The client
+// never sees it, but logs and metrics do.
+const StatusClientClosedRequest = 499
+
+// Details is an RFC 7807 problem details object. Code is an extension
+// member identifying the failure; clients should branch on it rather than
+// on Detail.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// Errors lists the invalid fields of a validation problem.
+	Errors []apperr.FieldError `json:"errors,omitempty"`
+}
+
+// Write sends a problem response with the given status, code and detail.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	write(w, newDetails(r, status, code, detail))
+}
+
+func newDetails(r *http.Request, status int, code, detail string) Details {
+	return Details{
+		Type:     "about:blank",
+		Title:    statusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+// Error sends the problem response matching err. Errors of a known kind
+// keep their code and message; anything else is logged and reported as an
+// opaque internal error. Logs are written with the request's context, so
+// they carry its trace ID.
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := apperr.As(err)
+	if !ok || e.Kind == apperr.KindInternal {
+		logger.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+		Write(w, r, http.StatusInternalServerError, "internal", "An unexpected error occurred")
+		return
+	}
+	if e.Kind == apperr.KindUnavailable || e.Kind == apperr.KindTimeout {
+		logger.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+	d := newDetails(r, Status(e.Kind), e.Code, e.Message)
+	d.Errors = e.Fields
+	write(w, d)
+}
+
+// Status returns the HTTP status code of an error kind.
+func Status(kind apperr.Kind) int {
+	switch kind {
+	case apperr.KindValidation:
+		return http.StatusBadRequest
+	case apperr.KindNotFound:
+		return http.StatusNotFound
+	case apperr.KindConflict:
+		return http.StatusConflict
+	case apperr.KindPrecondition:
+		return http.StatusPreconditionFailed
+	case apperr.KindUnavailable:
+		return http.StatusServiceUnavailable
+	case apperr.KindTimeout:
+		return http.StatusGatewayTimeout
+	case apperr.KindCanceled:
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func statusText(status int) string {
+	if status == StatusClientClosedRequest {
+		return "Client Closed Request"
+	}
+	return http.StatusText(status)
+}
+
+func write(w http.ResponseWriter, d Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(d.Status)
+	json.NewEncoder(w).Encode(d)
+}
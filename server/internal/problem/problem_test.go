@@ -0,0 +1,99 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"leaderboard/internal/apperr"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	secret := errors.New("pq: password authentication failed for user leaderboard")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{name: "validation", err: apperr.Validation("rating_negative", "rating cannot be negative"), wantStatus: 400, wantCode: "rating_negative", wantDetail: "rating cannot be negative"},
+		{name: "not found", err: apperr.NotFound("user_not_found", "user not found"), wantStatus: 404, wantCode: "user_not_found", wantDetail: "user not found"},
+		{name: "conflict", err: apperr.Conflict("username_taken", "username is already taken"), wantStatus: 409, wantCode: "username_taken", wantDetail: "username is already taken"},
+		{name: "precondition", err: apperr.PreconditionFailed("version_conflict", "user has changed"), wantStatus: 412, wantCode: "version_conflict", wantDetail: "user has changed"},
+		{name: "unavailable", err: apperr.Unavailable("database_unavailable", "database is unavailable", secret), wantStatus: 503, wantCode: "database_unavailable", wantDetail: "database is unavailable"},
+		{name: "timeout", err: apperr.Timeout("timeout", "operation timed out"), wantStatus: 504, wantCode: "timeout", wantDetail: "operation timed out"},
+		{name: "canceled", err: apperr.Canceled("canceled", "request canceled"), wantStatus: 499, wantCode: "canceled", wantDetail: "request canceled"},
+		{name: "wrapped", err: fmt.Errorf("loading user: %w", apperr.NotFound("user_not_found", "user not found")), wantStatus: 404, wantCode: "user_not_found", wantDetail: "user not found"},
+		{name: "unknown", err: secret, wantStatus: 500, wantCode: "internal", wantDetail: "An unexpected error occurred"},
+		{name: "wrapped unknown", err: fmt.Errorf("creating user: %w", secret), wantStatus: 500, wantCode: "internal", wantDetail: "An unexpected error occurred"},
+		{name: "internal kind", err: &apperr.Error{Kind: apperr.KindInternal, Code: "broken", Message: secret.Error()}, wantStatus: 500, wantCode: "internal", wantDetail: "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Error(w, httptest.NewRequest("GET", "/v1/users/1", nil), tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != ContentType {
+				t.Errorf("Content-Type = %q, want %q", ct, ContentType)
+			}
+			if strings.Contains(w.Body.String(), "password") {
+				t.Errorf("body leaks the error: %s", w.Body)
+			}
+
+			var d Details
+			if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+				t.Fatalf("body is not problem details: %v", err)
+			}
+			want := Details{Type: "about:blank", Title: statusText(tt.wantStatus), Status: tt.wantStatus, Detail: tt.wantDetail, Instance: "/v1/users/1", Code: tt.wantCode}
+			if d.Type != want.Type || d.Title != want.Title || d.Status != want.Status || d.Detail != want.Detail || d.Instance != want.Instance || d.Code != want.Code {
+				t.Errorf("body = %+v, want %+v", d, want)
+			}
+		})
+	}
+}
+
+func TestErrorFields(t *testing.T) {
+	fields := []apperr.FieldError{{Field: "username", Code: "too_short", Message: "must be at least 3 characters"}}
+	w := httptest.NewRecorder()
+	Error(w, httptest.NewRequest("POST", "/v1/users", nil), apperr.InvalidFields("username_invalid", "username does not meet the username policy", fields))
+
+	var d Details
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusBadRequest || len(d.Errors) != 1 || d.Errors[0] != fields[0] {
+		t.Errorf("got %d with errors %+v, want 400 with %+v", w.Code, d.Errors, fields)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		kind apperr.Kind
+		want int
+	}{
+		{apperr.KindInternal, 500},
+		{apperr.KindValidation, 400},
+		{apperr.KindNotFound, 404},
+		{apperr.KindConflict, 409},
+		{apperr.KindPrecondition, 412},
+		{apperr.KindUnavailable, 503},
+		{apperr.KindTimeout, 504},
+		{apperr.KindCanceled, 499},
+		{apperr.Kind(99), 500},
+	}
+
+	for _, tt := range tests {
+		if got := Status(tt.kind); got != tt.want {
+			t.Errorf("Status(%s) = %d, want %d", tt.kind, got, tt.want)
+		}
+	}
+}
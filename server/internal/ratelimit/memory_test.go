@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	ctx := context.Background()
+	// A rate this low doesn't refill noticeably while the test runs.
+	limit := Limit{Rate: 0.001, Burst: 3}
+	l := NewMemoryLimiter()
+
+	for i := range limit.Burst {
+		res, err := l.Allow(ctx, "a", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed || res.Remaining != limit.Burst-1-i {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i, res, limit.Burst-1-i)
+		}
+	}
+
+	res, err := l.Allow(ctx, "a", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed || res.RetryAfter <= 0 {
+		t.Errorf("request over the burst: %+v, want rejected with a Retry-After", res)
+	}
+
+	res, err = l.Allow(ctx, "b", limit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Allowed {
+		t.Errorf("other key: %+v, want allowed", res)
+	}
+}
+
+func TestMemoryLimiterSweep(t *testing.T) {
+	l := NewMemoryLimiter()
+	limit := Limit{Rate: 1000, Burst: 1}
+	if _, err := l.Allow(context.Background(), "a", limit); err != nil {
+		t.Fatal(err)
+	}
+
+	l.sweep(time.Now().Add(time.Second))
+	if len(l.buckets) != 0 {
+		t.Errorf("%d buckets left after they refilled, want 0", len(l.buckets))
+	}
+}
@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 2}
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		wantTokens float64
+		want       Result
+	}{
+		{name: "full", tokens: 2, wantTokens: 1, want: Result{Allowed: true, Remaining: 1, Reset: time.Second}},
+		{name: "last token", tokens: 1, wantTokens: 0, want: Result{Allowed: true, Remaining: 0, Reset: 2 * time.Second}},
+		{name: "empty", tokens: 0, wantTokens: 0, want: Result{RetryAfter: time.Second, Reset: 2 * time.Second}},
+		{name: "partly refilled", tokens: 0, elapsed: 500 * time.Millisecond, wantTokens: 0.5, want: Result{RetryAfter: 500 * time.Millisecond, Reset: 1500 * time.Millisecond}},
+		{name: "refilled one token", tokens: 0, elapsed: time.Second, wantTokens: 0, want: Result{Allowed: true, Reset: 2 * time.Second}},
+		{name: "refill capped at burst", tokens: 0, elapsed: time.Hour, wantTokens: 1, want: Result{Allowed: true, Remaining: 1, Reset: time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, res := take(tt.tokens, tt.elapsed, limit)
+			if tokens != tt.wantTokens {
+				t.Errorf("tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+			if res != tt.want {
+				t.Errorf("result = %+v, want %+v", res, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	tests := []struct {
+		limit      Limit
+		enabled    bool
+		wantWindow time.Duration
+	}{
+		{limit: Limit{Rate: 10, Burst: 20}, enabled: true, wantWindow: 2 * time.Second},
+		{limit: Limit{Rate: 0.5, Burst: 1}, enabled: true, wantWindow: 2 * time.Second},
+		{limit: Limit{Rate: 0, Burst: 20}},
+		{limit: Limit{Rate: 10, Burst: 0}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.Enabled(); got != tt.enabled {
+			t.Errorf("%+v.Enabled() = %v, want %v", tt.limit, got, tt.enabled)
+		}
+		if tt.enabled {
+			if got := tt.limit.Window(); got != tt.wantWindow {
+				t.Errorf("%+v.Window() = %s, want %s", tt.limit, got, tt.wantWindow)
+			}
+		}
+	}
+}
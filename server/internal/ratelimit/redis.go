@@ -0,0 +1,83 @@
+package ratelimit
+
+import (
+	"context"
+	"leaderboard/internal/logging"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var logger = logging.Logger("ratelimit")
+
+// keyPrefix namespaces the bucket keys in Redis.
+const keyPrefix = "ratelimit:"
+
+// takeScript refills and takes from a bucket stored as a hash in one step,
+// so concurrent requests on different instances can't both spend the last
+// token. The tokens left are returned as a string because Redis truncates
+// Lua numbers to integers.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisLimiter keeps buckets in Redis so every instance shares them. When
+// Redis can't be reached it counts requests in this process instead.
+type RedisLimiter struct {
+	rdb      *redis.Client
+	fallback *MemoryLimiter
+	// degraded is set while requests are counted by the fallback, so
+	// switching to it and back is logged once rather than per request.
+	degraded atomic.Bool
+}
+
+func NewRedisLimiter(rdb *redis.Client) *RedisLimiter {
+	return &RedisLimiter{rdb: rdb, fallback: NewMemoryLimiter()}
+}
+
+// Allow implements Limiter.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	args := []any{limit.Rate, limit.Burst, time.Now().UnixMilli()}
+	reply, err := takeScript.Run(ctx, l.rdb, []string{keyPrefix + key}, args...).Slice()
+	if err != nil {
+		// A request that gave up says nothing about Redis.
+		if ctx.Err() == nil && !l.degraded.Swap(true) {
+			logger.WarnContext(ctx, "Redis rate limiter unavailable, limiting in memory", "error", err)
+		}
+		return l.fallback.Allow(ctx, key, limit)
+	}
+	if l.degraded.Swap(false) {
+		logger.InfoContext(ctx, "Redis rate limiter available again")
+	}
+
+	allowed, _ := reply[0].(int64)
+	left, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(left, 64)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// The script already took the token; recompute the headers from what
+	// is left without any refill.
+	_, res := take(tokens+float64(allowed), 0, limit)
+	return res, nil
+}
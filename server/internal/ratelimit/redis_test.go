@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"bytes"
+	"context"
+	"leaderboard/internal/logging"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisLimiterFallback(t *testing.T) {
+	var logs bytes.Buffer
+	logging.Setup(logging.NewHandler(&logs, "text"), logging.Levels{Default: slog.LevelInfo})
+	t.Cleanup(func() {
+		logging.Setup(logging.NewHandler(os.Stderr, "text"), logging.Levels{Default: slog.LevelInfo})
+	})
+
+	// Nothing listens on port 1, so every call falls back.
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer rdb.Close()
+	l := NewRedisLimiter(rdb)
+
+	limit := Limit{Rate: 1, Burst: 2}
+	var allowed int
+	for range 3 {
+		res, err := l.Allow(context.Background(), "ip:192.0.2.1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Allowed {
+			allowed++
+		}
+	}
+
+	if allowed != limit.Burst {
+		t.Errorf("fallback allowed %d of 3 requests, want %d", allowed, limit.Burst)
+	}
+	if n := strings.Count(logs.String(), "limiting in memory"); n != 1 {
+		t.Errorf("fallback logged %d times, want once:\n%s", n, logs.String())
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"leaderboard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository stores API keys. Every method stops when ctx is done,
+// returning ErrTimeout or ErrCanceled.
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *models.APIKey) error
+	// GetActiveByHash returns the unrevoked key with the given hash.
+	GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	// Revoke revokes an active key. It returns ErrAPIKeyNotFound if
+	// there is no such key or it is already revoked.
+	Revoke(ctx context.Context, id int) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
+type PostgresAPIKeyRepository struct {
+	db       *gorm.DB
+	timeouts timeouts
+}
+
+func NewPostgresAPIKeyRepository(db *gorm.DB, timeouts Timeouts) *PostgresAPIKeyRepository {
+	r := &PostgresAPIKeyRepository{db: db}
+	r.timeouts.set(timeouts)
+	return r
+}
+
+// SetTimeouts changes the deadlines of operations started from now on.
+// Keys are read with the Read timeout and written with the Write timeout.
+func (r *PostgresAPIKeyRepository) SetTimeouts(timeouts Timeouts) {
+	r.timeouts.set(timeouts)
+}
+
+// Create implements APIKeyRepository.
+func (r *PostgresAPIKeyRepository) Create(ctx context.Context, k *models.APIKey) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+
+	return dbError(r.db.WithContext(ctx).Create(k).Error, nil)
+}
+
+// GetActiveByHash implements APIKeyRepository.
+func (r *PostgresAPIKeyRepository) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", keyHash).First(&key).Error
+	if err != nil {
+		return nil, dbError(err, ErrAPIKeyNotFound)
+	}
+	return &key, nil
+}
+
+// List implements APIKeyRepository.
+func (r *PostgresAPIKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Order("id").Find(&keys).Error
+	return keys, dbError(err, nil)
+}
+
+// Revoke implements APIKeyRepository.
+func (r *PostgresAPIKeyRepository) Revoke(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+
+	res := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return dbError(res.Error, nil)
+	}
+	if res.RowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// TouchLastUsed implements APIKeyRepository.
+func (r *PostgresAPIKeyRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+
+	return dbError(r.db.WithContext(ctx).Model(&models.APIKey{}).Where("id = ?", id).Update("last_used_at", at).Error, nil)
+}
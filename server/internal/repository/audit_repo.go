@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"leaderboard/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AuditRepository stores the audit log. Every method stops when ctx is
+// done, returning ErrTimeout or ErrCanceled.
+type AuditRepository interface {
+	Record(ctx context.Context, e *models.AuditEntry) error
+	// List returns up to limit entries, newest first, older than the entry
+	// with ID before, or the newest ones when before is 0.
+	List(ctx context.Context, limit int, before int64) ([]models.AuditEntry, error)
+}
+
+type PostgresAuditRepository struct {
+	db       *gorm.DB
+	timeouts timeouts
+}
+
+func NewPostgresAuditRepository(db *gorm.DB, timeouts Timeouts) *PostgresAuditRepository {
+	r := &PostgresAuditRepository{db: db}
+	r.timeouts.set(timeouts)
+	return r
+}
+
+// SetTimeouts changes the deadlines of operations started from now on.
+// Entries are read with the Read timeout and written with the Write
+// timeout.
+func (r *PostgresAuditRepository) SetTimeouts(timeouts Timeouts) {
+	r.timeouts.set(timeouts)
+}
+
+// Record implements AuditRepository.
+func (r *PostgresAuditRepository) Record(ctx context.Context, e *models.AuditEntry) error {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+
+	return dbError(r.db.WithContext(ctx).Create(e).Error, nil)
+}
+
+// List implements AuditRepository.
+func (r *PostgresAuditRepository) List(ctx context.Context, limit int, before int64) ([]models.AuditEntry, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	query := r.db.WithContext(ctx).Order("id DESC").Limit(limit)
+	if before > 0 {
+		query = query.Where("id < ?", before)
+	}
+	var entries []models.AuditEntry
+	return entries, dbError(query.Find(&entries).Error, nil)
+}
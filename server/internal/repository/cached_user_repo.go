@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"context"
+	"leaderboard/internal/events"
+	"leaderboard/internal/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheStats reports how the hot-page cache is being used.
+type CacheStats struct {
+	Hits          uint64        `json:"hits"`
+	Misses        uint64        `json:"misses"`
+	Loads         uint64        `json:"loads"`
+	Invalidations uint64        `json:"invalidations"`
+	Size          int           `json:"size"`
+	Age           time.Duration `json:"age"`
+}
+
+// CachedUserRepository keeps the top of the leaderboard in memory in front
+// of another UserRepository. Every page within the first topK entries is
+// served from the cache; everything else goes to the wrapped repository.
+//
+// The cache follows leaderboard events and drops its entries when a write
+// can affect them. It only answers while it has seen every event up to the
+// current leaderboard version, so a page is never older than the version a
+// caller has just read. Concurrent misses share a single load, which
+// carries on when a caller gives up on it, since others may be waiting.
+type CachedUserRepository struct {
+	UserRepository
+
+	topK int
+	// maxStale is a time.Duration, changed by SetMaxStale.
+	maxStale atomic.Int64
+	group    singleflight.Group
+
+	mu sync.RWMutex
+	// entries is valid as of version seen unless invalidated.
+	entries    []UserWithRank
+	valid      bool
+	loadedAt   time.Time
+	seen       int64
+	generation uint64
+
+	hits          atomic.Uint64
+	misses        atomic.Uint64
+	loads         atomic.Uint64
+	invalidations atomic.Uint64
+
+	unsubscribe func()
+	done        chan struct{}
+}
+
+type cacheLoad struct {
+	version int64
+	entries []UserWithRank
+}
+
+func NewCachedUserRepository(inner UserRepository, bus events.Bus, topK int, maxStale time.Duration) *CachedUserRepository {
+	c := &CachedUserRepository{
+		UserRepository: inner,
+		topK:           topK,
+		done:           make(chan struct{}),
+	}
+	c.maxStale.Store(int64(maxStale))
+
+	ch, unsubscribe := bus.Subscribe()
+	c.unsubscribe = unsubscribe
+	go c.run(ch)
+	return c
+}
+
+// SetMaxStale changes how long cached entries may be served without being
+// reloaded.
+func (c *CachedUserRepository) SetMaxStale(maxStale time.Duration) {
+	c.maxStale.Store(int64(maxStale))
+}
+
+// Close stops following leaderboard events.
+func (c *CachedUserRepository) Close() {
+	c.unsubscribe()
+	<-c.done
+}
+
+// GetLeaderboard implements UserRepository.
+func (c *CachedUserRepository) GetLeaderboard(ctx context.Context, limit int, offset int) ([]UserWithRank, error) {
+	if offset+limit > c.topK {
+		return c.UserRepository.GetLeaderboard(ctx, limit, offset)
+	}
+
+	version, err := c.UserRepository.GetVersion(ctx)
+	if err != nil {
+		return c.UserRepository.GetLeaderboard(ctx, limit, offset)
+	}
+
+	c.mu.RLock()
+	fresh := c.valid && c.seen >= version && time.Since(c.loadedAt) < time.Duration(c.maxStale.Load())
+	entries := c.entries
+	c.mu.RUnlock()
+
+	if fresh {
+		c.hits.Add(1)
+		metrics.CacheHits.Inc()
+		return page(entries, limit, offset), nil
+	}
+	c.misses.Add(1)
+	metrics.CacheMisses.Inc()
+
+	// A load that was already in flight may have started before the write
+	// that produced version; join at most one more in that case.
+	load := func() (any, error) { return c.load(context.WithoutCancel(ctx)) }
+	for attempt := 0; ; attempt++ {
+		var res singleflight.Result
+		select {
+		case res = <-c.group.DoChan("top", load):
+		case <-ctx.Done():
+			return nil, contextError(ctx.Err())
+		}
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		loaded := res.Val.(cacheLoad)
+		if loaded.version >= version || attempt == 1 {
+			return page(loaded.entries, limit, offset), nil
+		}
+	}
+}
+
+// Stats returns cache usage counters.
+func (c *CachedUserRepository) Stats() CacheStats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	stats := CacheStats{
+		Hits:          c.hits.Load(),
+		Misses:        c.misses.Load(),
+		Loads:         c.loads.Load(),
+		Invalidations: c.invalidations.Load(),
+	}
+	if c.valid {
+		stats.Size = len(c.entries)
+		stats.Age = time.Since(c.loadedAt)
+	}
+	return stats
+}
+
+func (c *CachedUserRepository) load(ctx context.Context) (any, error) {
+	c.loads.Add(1)
+
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	// Read the version first so the entries are at least that recent.
+	version, err := c.UserRepository.GetVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := c.UserRepository.GetLeaderboard(ctx, c.topK, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Don't keep the result if an event invalidated the cache meanwhile.
+	if c.generation == generation {
+		c.entries = entries
+		c.valid = true
+		c.loadedAt = time.Now()
+		c.seen = max(c.seen, version)
+	}
+	return cacheLoad{version: version, entries: entries}, nil
+}
+
+func (c *CachedUserRepository) run(ch <-chan events.Event) {
+	defer close(c.done)
+
+	for e := range ch {
+		c.apply(e)
+	}
+}
+
+func (c *CachedUserRepository) apply(e events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seen = max(c.seen, e.Version)
+	if c.valid && !c.affects(e) {
+		return
+	}
+
+	// While the cache is invalid there are no entries to check the event
+	// against, so it voids any load in flight.
+	if c.valid {
+		c.valid = false
+		c.invalidations.Add(1)
+	}
+	c.generation++
+}
+
+// affects reports whether an event can change the cached entries. Callers
+// must hold c.mu.
+func (c *CachedUserRepository) affects(e events.Event) bool {
+	if e.Type == events.LeaderboardReset || len(c.entries) < c.topK {
+		return true
+	}
+	cutoff := c.entries[len(c.entries)-1].Rating
+	return e.NewRating >= cutoff || (e.Type == events.RatingUpdated && e.OldRating >= cutoff)
+}
+
+// page returns a copy of entries[offset:offset+limit], clamped to entries.
+func page(entries []UserWithRank, limit, offset int) []UserWithRank {
+	if offset >= len(entries) {
+		return []UserWithRank{}
+	}
+	end := min(offset+limit, len(entries))
+	out := make([]UserWithRank, end-offset)
+	copy(out, entries[offset:end])
+	return out
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"leaderboard/internal/events"
+	"leaderboard/internal/metrics"
+	"leaderboard/internal/models"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// stubUserRepository serves a fixed leaderboard. When gate is set,
+// GetLeaderboard reports on started and then waits for gate to close.
+type stubUserRepository struct {
+	UserRepository
+
+	version atomic.Int64
+	entries []UserWithRank
+	calls   atomic.Int32
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func newStubUserRepository(version int64, ratings ...int) *stubUserRepository {
+	r := &stubUserRepository{}
+	r.version.Store(version)
+	for i, rating := range ratings {
+		r.entries = append(r.entries, UserWithRank{User: models.User{ID: i + 1, Rating: rating}, Rank: i + 1})
+	}
+	return r
+}
+
+// hold makes GetLeaderboard wait until the returned function is called.
+func (r *stubUserRepository) hold() func() {
+	r.started = make(chan struct{}, 100)
+	r.gate = make(chan struct{})
+	return func() { close(r.gate) }
+}
+
+func (r *stubUserRepository) GetVersion(ctx context.Context) (int64, error) {
+	return r.version.Load(), nil
+}
+
+func (r *stubUserRepository) GetLeaderboard(ctx context.Context, limit, offset int) ([]UserWithRank, error) {
+	r.calls.Add(1)
+	if r.gate != nil {
+		r.started <- struct{}{}
+		<-r.gate
+	}
+	return page(r.entries, limit, offset), nil
+}
+
+func newTestCache(t *testing.T, inner UserRepository) *CachedUserRepository {
+	t.Helper()
+	bus := events.NewInMemoryBus()
+	c := NewCachedUserRepository(inner, bus, 3, time.Hour)
+	t.Cleanup(func() {
+		c.Close()
+		bus.Close()
+	})
+	return c
+}
+
+func TestCacheInvalidatedDuringLoad(t *testing.T) {
+	tests := []struct {
+		name string
+		// warm loads the cache before the load under test, which then runs
+		// because the entries went stale.
+		warm bool
+	}{
+		{name: "cold"},
+		{name: "stale", warm: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newStubUserRepository(5, 100, 90, 80)
+			c := newTestCache(t, inner)
+			ctx := context.Background()
+			if tt.warm {
+				if _, err := c.GetLeaderboard(ctx, 3, 0); err != nil {
+					t.Fatal(err)
+				}
+				c.SetMaxStale(0)
+			}
+			calls := inner.calls.Load()
+
+			release := inner.hold()
+			done := make(chan error)
+			go func() {
+				_, err := c.GetLeaderboard(ctx, 3, 0)
+				done <- err
+			}()
+			<-inner.started
+
+			// A write lands after the load read the leaderboard.
+			inner.version.Store(6)
+			c.apply(events.Event{Type: events.RatingUpdated, Version: 6, UserID: 4, OldRating: 10, NewRating: 95})
+			release()
+			if err := <-done; err != nil {
+				t.Fatal(err)
+			}
+
+			if stats := c.Stats(); stats.Size != 0 {
+				t.Errorf("cache holds %d entries loaded before the write", stats.Size)
+			}
+			c.SetMaxStale(time.Hour)
+			if _, err := c.GetLeaderboard(ctx, 3, 0); err != nil {
+				t.Fatal(err)
+			}
+			if got := inner.calls.Load() - calls; got != 2 {
+				t.Errorf("leaderboard loaded %d times, want 2", got)
+			}
+		})
+	}
+}
+
+func TestCacheIgnoresEventsBelowTop(t *testing.T) {
+	inner := newStubUserRepository(5, 100, 90, 80)
+	c := newTestCache(t, inner)
+	ctx := context.Background()
+	if _, err := c.GetLeaderboard(ctx, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	inner.version.Store(6)
+	c.apply(events.Event{Type: events.RatingUpdated, Version: 6, UserID: 4, OldRating: 10, NewRating: 20})
+	if _, err := c.GetLeaderboard(ctx, 3, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := inner.calls.Load(); got != 1 {
+		t.Errorf("leaderboard loaded %d times, want 1", got)
+	}
+
+	c.apply(events.Event{Type: events.LeaderboardReset, Version: 7})
+	if stats := c.Stats(); stats.Size != 0 || stats.Invalidations != 1 {
+		t.Errorf("after a reset the cache holds %d entries with %d invalidations, want none and 1", stats.Size, stats.Invalidations)
+	}
+}
+
+func TestCacheCollapsesMisses(t *testing.T) {
+	const callers = 10
+	inner := newStubUserRepository(5, 100, 90, 80)
+	c := newTestCache(t, inner)
+	release := inner.hold()
+
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Go(func() {
+			users, err := c.GetLeaderboard(context.Background(), 2, 1)
+			if err != nil || len(users) != 2 || users[0].ID != 2 {
+				t.Errorf("GetLeaderboard = %+v, %v", users, err)
+			}
+		})
+	}
+	<-inner.started
+	// Give every caller time to join the load before it finishes.
+	for c.Stats().Misses < callers {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	release()
+	wg.Wait()
+
+	if got := inner.calls.Load(); got != 1 {
+		t.Errorf("%d concurrent misses made %d loads, want 1", callers, got)
+	}
+}
+
+func TestCacheCounters(t *testing.T) {
+	inner := newStubUserRepository(5, 100, 90, 80)
+	c := newTestCache(t, inner)
+	ctx := context.Background()
+	hits, misses := testutil.ToFloat64(metrics.CacheHits), testutil.ToFloat64(metrics.CacheMisses)
+
+	for range 3 {
+		if _, err := c.GetLeaderboard(ctx, 3, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Pages beyond the top aren't cached and count as neither.
+	if _, err := c.GetLeaderboard(ctx, 3, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Loads != 1 || stats.Size != 3 {
+		t.Errorf("stats = %+v, want 2 hits, 1 miss, 1 load and 3 entries", stats)
+	}
+	if got := testutil.ToFloat64(metrics.CacheHits) - hits; got != 2 {
+		t.Errorf("hits metric moved by %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.CacheMisses) - misses; got != 1 {
+		t.Errorf("misses metric moved by %v, want 1", got)
+	}
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// LeaderboardChangesKey holds the most recent leaderboard changes,
+	// newest first.
+	LeaderboardChangesKey = "global_leaderboard:changes"
+
+	// ChangeLogSize bounds how many changes are retained. Clients that fall
+	// further behind than this have to do a full refresh.
+	ChangeLogSize = 10000
+)
+
+// changesSinceScript reads the current version together with the entries
+// made after ARGV[1], so no write can slip in between the two reads. It
+// returns the version followed by the entries, newest first; no entries are
+// returned when the log can't possibly cover the gap. A missing version
+// starts from ARGV[3].
+var changesSinceScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[3], 'NX')
+local v = tonumber(redis.call('GET', KEYS[1]))
+local n = v - tonumber(ARGV[1])
+local res = {tostring(v)}
+if n <= 0 or n > tonumber(ARGV[2]) then
+	return res
+end
+for _, e in ipairs(redis.call('LRANGE', KEYS[2], 0, n - 1)) do
+	table.insert(res, e)
+end
+return res
+`)
+
+// ErrChangeLogTruncated is returned when the change log no longer holds
+// every change made after the requested version. The current version is
+// returned along with it, as it is with ErrChangeLogCorrupt when only an
+// entry can't be read.
+var ErrChangeLogTruncated = errors.New("change log truncated")
+
+// Change is one entry of the leaderboard change log.
+type Change struct {
+	Version   int64  `json:"version"`
+	UserID    int    `json:"user_id"`
+	Username  string `json:"username"`
+	OldRating int    `json:"old_rating"`
+	NewRating int    `json:"new_rating"`
+	// Created is set when the user did not exist before this change.
+	Created bool `json:"created,omitempty"`
+	// Reset is set when the whole leaderboard was rebuilt, e.g. by a
+	// resync, so earlier versions can't be brought up to date incrementally.
+	Reset bool `json:"reset,omitempty"`
+}
+
+// GetChangesSince implements UserRepository.
+func (r *PostgresUserRepository) GetChangesSince(ctx context.Context, version int64) ([]Change, int64, error) {
+	if r.rdb == nil {
+		return r.changes.since(version)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	if err := r.recordLostChange(ctx); err != nil {
+		return nil, 0, err
+	}
+	res, err := changesSinceScript.Run(ctx, r.rdb,
+		[]string{LeaderboardVersionKey, LeaderboardChangesKey}, version, ChangeLogSize, initialVersion()).StringSlice()
+	if err != nil {
+		return nil, 0, redisError(err)
+	}
+
+	current, err := strconv.ParseInt(res[0], 10, 64)
+	if err != nil {
+		return nil, 0, ErrChangeLogCorrupt.Wrap(err)
+	}
+	if version == current {
+		return []Change{}, current, nil
+	}
+	entries := res[1:]
+
+	changes := make([]Change, 0, len(entries))
+	// Entries are newest first; walk them backwards to return oldest first.
+	for i := len(entries) - 1; i >= 0; i-- {
+		c, err := parseChangeEntry(entries[i])
+		if err != nil {
+			logger.ErrorContext(ctx, "Unreadable change log entry", "entry", entries[i], "error", err)
+			return nil, current, ErrChangeLogCorrupt.Wrap(err)
+		}
+		if c.Version > version && c.Version <= current {
+			changes = append(changes, c)
+		}
+	}
+
+	if len(changes) == 0 || changes[0].Version != version+1 {
+		return nil, current, ErrChangeLogTruncated
+	}
+	return changes, current, nil
+}
+
+func parseChangeEntry(entry string) (Change, error) {
+	var c Change
+	versionStr, payload, ok := strings.Cut(entry, ":")
+	if !ok {
+		return c, errors.New("malformed change log entry")
+	}
+	if err := json.Unmarshal([]byte(payload), &c); err != nil {
+		return c, err
+	}
+	v, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return c, err
+	}
+	c.Version = v
+	return c, nil
+}
+
+// memoryChangeLog keeps the leaderboard version and change log when running
+// without Redis.
+type memoryChangeLog struct {
+	mu      sync.Mutex
+	version int64
+	entries []Change
+}
+
+func newMemoryChangeLog() *memoryChangeLog {
+	return &memoryChangeLog{version: initialVersion()}
+}
+
+func (l *memoryChangeLog) currentVersion() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.version
+}
+
+func (l *memoryChangeLog) append(c Change) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.version++
+	c.Version = l.version
+
+	if len(l.entries) == ChangeLogSize {
+		copy(l.entries, l.entries[1:])
+		l.entries = l.entries[:len(l.entries)-1]
+	}
+	l.entries = append(l.entries, c)
+	return l.version
+}
+
+func (l *memoryChangeLog) since(version int64) ([]Change, int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if version == l.version {
+		return []Change{}, l.version, nil
+	}
+	if version > l.version || len(l.entries) == 0 || l.entries[0].Version > version+1 {
+		return nil, l.version, ErrChangeLogTruncated
+	}
+
+	// Entries are contiguous, so the first change after version sits at a
+	// fixed offset from the oldest one.
+	start := int(version + 1 - l.entries[0].Version)
+	changes := make([]Change, len(l.entries)-start)
+	copy(changes, l.entries[start:])
+	return changes, l.version, nil
+}
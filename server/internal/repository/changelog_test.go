@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryChangeLogSince(t *testing.T) {
+	l := newMemoryChangeLog()
+	first := l.currentVersion()
+	for i := range 3 {
+		l.append(Change{UserID: i + 1})
+	}
+
+	tests := []struct {
+		name      string
+		since     int64
+		wantUsers []int
+		wantErr   error
+	}{
+		{name: "all", since: first, wantUsers: []int{1, 2, 3}},
+		{name: "some", since: first + 1, wantUsers: []int{2, 3}},
+		{name: "current", since: first + 3, wantUsers: []int{}},
+		{name: "before the log", since: first - 1, wantErr: ErrChangeLogTruncated},
+		{name: "ahead of the log", since: first + 4, wantErr: ErrChangeLogTruncated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, version, err := l.since(tt.since)
+			if version != first+3 {
+				t.Errorf("version = %d, want %d", version, first+3)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if changes != nil {
+					t.Errorf("got %d changes along with the error", len(changes))
+				}
+				return
+			}
+			if len(changes) != len(tt.wantUsers) {
+				t.Fatalf("got %d changes, want %d", len(changes), len(tt.wantUsers))
+			}
+			for i, c := range changes {
+				if c.UserID != tt.wantUsers[i] || c.Version != tt.since+int64(i)+1 {
+					t.Errorf("change %d is user %d at version %d, want user %d at version %d",
+						i, c.UserID, c.Version, tt.wantUsers[i], tt.since+int64(i)+1)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryChangeLogEviction(t *testing.T) {
+	l := newMemoryChangeLog()
+	first := l.currentVersion()
+	for range ChangeLogSize + 1 {
+		l.append(Change{})
+	}
+
+	// The first change was evicted, so catching up from before it must
+	// fail outright rather than return the changes that are left.
+	changes, version, err := l.since(first)
+	if !errors.Is(err, ErrChangeLogTruncated) || changes != nil {
+		t.Errorf("since(%d) = %d changes, %v, want ErrChangeLogTruncated", first, len(changes), err)
+	}
+	if version != first+ChangeLogSize+1 {
+		t.Errorf("version = %d, want %d", version, first+ChangeLogSize+1)
+	}
+
+	changes, _, err = l.since(first + 1)
+	if err != nil || len(changes) != ChangeLogSize {
+		t.Errorf("since(%d) = %d changes, %v, want all %d", first+1, len(changes), err, ChangeLogSize)
+	}
+}
+
+func TestParseChangeEntry(t *testing.T) {
+	tests := []struct {
+		entry   string
+		want    Change
+		wantErr bool
+	}{
+		{entry: `7:{"user_id":3,"old_rating":10,"new_rating":20}`, want: Change{Version: 7, UserID: 3, OldRating: 10, NewRating: 20}},
+		{entry: `8:{"reset":true}`, want: Change{Version: 8, Reset: true}},
+		{entry: `{"user_id":3}`, wantErr: true},
+		{entry: `x:{"user_id":3}`, wantErr: true},
+		{entry: `7:{"user_id":`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseChangeEntry(tt.entry)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseChangeEntry(%q) error = %v, want error %v", tt.entry, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseChangeEntry(%q) = %+v, want %+v", tt.entry, got, tt.want)
+		}
+	}
+}
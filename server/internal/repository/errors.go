@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"leaderboard/internal/apperr"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound  = apperr.NotFound("user_not_found", "user not found")
+	ErrUsernameTaken = apperr.Conflict("username_taken", "username is already taken")
+	// ErrVersionConflict is returned by writes made against a version of a
+	// user that is no longer current.
+	ErrVersionConflict = apperr.PreconditionFailed("version_conflict", "user was modified concurrently")
+	ErrAPIKeyNotFound  = apperr.NotFound("api_key_not_found", "API key not found")
+
+	ErrDatabaseUnavailable = apperr.Unavailable("database_unavailable", "database is unavailable", nil)
+	ErrRedisUnavailable    = apperr.Unavailable("redis_unavailable", "Redis is unavailable", nil)
+	// ErrSyncPending is returned while the Redis leaderboard is being
+	// filled from Postgres.
+	ErrSyncPending = apperr.Unavailable("redis_sync_pending", "the Redis leaderboard is still being synced", nil)
+	// ErrChangeLogCorrupt is returned when Redis holds a leaderboard
+	// version or change log entry that can't be parsed.
+	ErrChangeLogCorrupt = apperr.Unavailable("change_log_corrupt", "the leaderboard change log can't be read", nil)
+
+	// ErrTimeout is returned when an operation runs past its deadline,
+	// set by the caller or by the repository's Timeouts.
+	ErrTimeout = apperr.Timeout("timeout", "the operation timed out")
+	// ErrCanceled is returned when the caller canceled an operation, such
+	// as a client that disconnected.
+	ErrCanceled = apperr.Canceled("canceled", "the operation was canceled")
+)
+
+// dbError translates an error from gorm: missing records become notFound,
+// lost connections ErrDatabaseUnavailable and expired or canceled contexts
+// ErrTimeout or ErrCanceled. Other errors are returned as they are.
+func dbError(err error, notFound *apperr.Error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := contextError(err); ctxErr != nil {
+		return ctxErr
+	}
+	switch {
+	case notFound != nil && errors.Is(err, gorm.ErrRecordNotFound):
+		return notFound
+	case isConnectionError(err):
+		return ErrDatabaseUnavailable.Wrap(err)
+	default:
+		return err
+	}
+}
+
+// redisError marks a failed Redis command as unavailable, since Redis only
+// fails when it can't be reached or is overloaded, unless the command was
+// cut short by its context.
+func redisError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctxErr := contextError(err); ctxErr != nil {
+		return ctxErr
+	}
+	return ErrRedisUnavailable.Wrap(err)
+}
+
+// contextError returns ErrTimeout or ErrCanceled if err was caused by a
+// context, nil otherwise.
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.Wrap(err)
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled.Wrap(err)
+	default:
+		return nil
+	}
+}
+
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, driver.ErrBadConn)
+}
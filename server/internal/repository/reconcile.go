@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"leaderboard/internal/events"
+	"leaderboard/internal/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ReconcileReport is what a reconciliation found wrong with the Redis
+// leaderboard, and fixed unless it was a dry run.
+type ReconcileReport struct {
+	// Users is the number of users in Postgres.
+	Users int
+	// Missing users are in Postgres but not in Redis.
+	Missing int
+	// Stale users are in Redis with a rating other than the one in
+	// Postgres.
+	Stale int
+	// Extra members are in Redis but match no user in Postgres.
+	Extra  int
+	DryRun bool
+	// Fixed is set when the differences were written to Redis.
+	Fixed bool
+}
+
+// Reconcile compares the Redis leaderboard with Postgres, which holds the
+// truth, and corrects the members that differ, unlike SyncToRedis which
+// rewrites them all. With dryRun it only reports the differences.
+//
+// Redis is read before Postgres, so a rating updated meanwhile is seen in
+// its new state in Postgres and written as such. A rating updated between
+// the Postgres read and the fix can still be overwritten with its previous
+// value; running Reconcile again corrects it.
+func (r *PostgresUserRepository) Reconcile(ctx context.Context, dryRun bool) (*ReconcileReport, error) {
+	if r.rdb == nil {
+		return nil, ErrRedisUnavailable
+	}
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Sync)
+	defer cancel()
+
+	members, err := r.rdb.ZRangeWithScores(ctx, LeaderboardKey, 0, -1).Result()
+	if err != nil {
+		return nil, redisError(err)
+	}
+	inRedis := make(map[string]float64, len(members))
+	for _, z := range members {
+		inRedis[z.Member.(string)] = z.Score
+	}
+
+	var users []models.User
+	if err := r.db.WithContext(ctx).Select("id", "username", "rating").Find(&users).Error; err != nil {
+		return nil, dbError(err, nil)
+	}
+
+	report := &ReconcileReport{Users: len(users), DryRun: dryRun}
+	var fixes []redis.Z
+	for _, u := range users {
+		member := fmt.Sprintf("%s:%d", u.Username, u.ID)
+		score, ok := inRedis[member]
+		delete(inRedis, member)
+		switch {
+		case !ok:
+			report.Missing++
+		case score != float64(u.Rating):
+			report.Stale++
+		default:
+			continue
+		}
+		fixes = append(fixes, redis.Z{Score: float64(u.Rating), Member: member})
+	}
+	report.Extra = len(inRedis)
+
+	if dryRun || (len(fixes) == 0 && len(inRedis) == 0) {
+		return report, nil
+	}
+
+	pipe := r.rdb.Pipeline()
+	if len(fixes) > 0 {
+		pipe.ZAdd(ctx, LeaderboardKey, fixes...)
+	}
+	for member := range inRedis {
+		pipe.ZRem(ctx, LeaderboardKey, member)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, redisError(err)
+	}
+	report.Fixed = true
+
+	// Ranks may have changed anywhere, so readers start over.
+	ctx = context.WithoutCancel(ctx)
+	r.announce(ctx, Change{Reset: true}, events.Event{Type: events.LeaderboardReset})
+	return report, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts bounds how long each kind of repository operation may take, on
+// top of any deadline the caller's context already has. Zero leaves that
+// kind of operation bounded by the caller alone.
+type Timeouts struct {
+	// Read bounds lookups of single users, pages and the change log.
+	Read time.Duration
+	// Write bounds creating users and updating ratings.
+	Write time.Duration
+	// Search bounds username searches, which scan more rows than reads.
+	Search time.Duration
+	// Sync bounds rebuilding the Redis leaderboard from Postgres and other
+	// reads of every user.
+	Sync time.Duration
+}
+
+// timeouts holds the Timeouts of a repository, which can change while it
+// is in use.
+type timeouts struct {
+	p atomic.Pointer[Timeouts]
+}
+
+func (t *timeouts) set(timeouts Timeouts) {
+	t.p.Store(&timeouts)
+}
+
+func (t *timeouts) get() Timeouts {
+	if p := t.p.Load(); p != nil {
+		return *p
+	}
+	return Timeouts{}
+}
+
+// withTimeout returns ctx with a deadline d from now, or ctx itself when d
+// is zero.
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, d)
+}
@@ -2,16 +2,24 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"leaderboard/internal/events"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/metrics"
 	"leaderboard/internal/models"
-	"log"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
+var logger = logging.Logger("repository")
+
 const LeaderboardKey = "global_leaderboard"
 
 type UserWithRank struct {
@@ -19,43 +27,144 @@ type UserWithRank struct {
 	Rank int `json:"rank"`
 }
 
+// UserRating is the rating of a user, without the rest of the user.
+type UserRating struct {
+	ID     int
+	Rating int
+}
+
+// UserRepository stores users and their leaderboard. Every method stops
+// when ctx is done, returning ErrTimeout or ErrCanceled.
 type UserRepository interface {
-	Create(u *models.User) error
-	UpdateRating(userID int, newRating int) error
-	GetByUsername(username string) (*models.User, error)
-	GetLeaderboard(limit, offset int) ([]UserWithRank, error)
-	SearchUsersWithRank(query string) ([]UserWithRank, error)
-	SyncToRedis() error
+	Create(ctx context.Context, u *models.User) error
+	// UpdateRating sets a user's rating and returns the updated user. A
+	// non-zero ifVersion makes the update fail with ErrVersionConflict
+	// unless it is the user's current version.
+	UpdateRating(ctx context.Context, userID int, newRating int, ifVersion int) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetUserWithRankByID(ctx context.Context, userID int) (*UserWithRank, error)
+	GetLeaderboard(ctx context.Context, limit, offset int) ([]UserWithRank, error)
+	SearchUsersWithRank(ctx context.Context, query string) ([]UserWithRank, error)
+	SyncToRedis(ctx context.Context) error
+	// ListRatings returns the ID and rating of every user, ordered by ID.
+	ListRatings(ctx context.Context) ([]UserRating, error)
+	// CountRatedAbove returns, for each of ratings, how many users are
+	// rated strictly higher.
+	CountRatedAbove(ctx context.Context, ratings []int) ([]int64, error)
+	// GetVersion returns the current leaderboard version. It increases on
+	// every write, so an unchanged version means an unchanged leaderboard.
+	GetVersion(ctx context.Context) (int64, error)
+	// GetChangesSince returns the changes made after version, oldest first,
+	// and the version they bring the caller up to. It returns
+	// ErrChangeLogTruncated when some of those changes are no longer kept,
+	// and ErrChangeLogCorrupt when they can't be read.
+	GetChangesSince(ctx context.Context, version int64) ([]Change, int64, error)
 }
 
 type PostgresUserRepository struct {
 	db  *gorm.DB
 	rdb *redis.Client
+	bus events.Bus
+
+	// changes holds the version and change log when running without Redis.
+	changes *memoryChangeLog
+	// changeLost is set when a change couldn't be recorded in Redis, until
+	// a reset is recorded in its place.
+	changeLost atomic.Bool
+
+	timeouts timeouts
+
+	// background tracks work running after the call that started it, such
+	// as the initial sync, so Close can wait for it. stopBackground cancels
+	// that work when Close runs out of time.
+	background     sync.WaitGroup
+	stopBackground context.CancelFunc
+
+	// initialSync is the outcome of the sync started with the repository.
+	// It is nil while the sync runs.
+	initialSync atomic.Pointer[syncResult]
+}
+
+type syncResult struct {
+	err error
 }
 
-func NewPostgresUserRepository(db *gorm.DB, rdb *redis.Client) UserRepository {
-	repo := &PostgresUserRepository{db: db, rdb: rdb}
+func NewPostgresUserRepository(db *gorm.DB, rdb *redis.Client, bus events.Bus, timeouts Timeouts) *PostgresUserRepository {
+	repo := &PostgresUserRepository{db: db, rdb: rdb, bus: bus, changes: newMemoryChangeLog()}
+	repo.timeouts.set(timeouts)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repo.stopBackground = cancel
+
 	// Initial sync on startup
-	go func() {
-		if rdb != nil {
-			log.Println("🔄 Initializing Redis leaderboard sync...")
-			if err := repo.SyncToRedis(); err != nil {
-				log.Printf("❌ Redis sync failed: %v", err)
+	if rdb != nil {
+		repo.background.Add(1)
+		go func() {
+			defer repo.background.Done()
+			logger.Info("Syncing Redis leaderboard")
+			err := repo.SyncToRedis(ctx)
+			if err != nil {
+				logger.Error("Redis leaderboard sync failed", "error", err)
 			} else {
-				log.Println("✅ Redis leaderboard sync completed")
+				logger.Info("Redis leaderboard sync completed")
 			}
-		}
-	}()
+			repo.initialSync.Store(&syncResult{err: err})
+		}()
+	} else {
+		repo.initialSync.Store(&syncResult{})
+	}
 	return repo
 }
 
-func (r *PostgresUserRepository) SyncToRedis() error {
+// InitialSync reports how the sync of the Redis leaderboard started with
+// the repository went: ErrSyncPending while it runs, then its error, nil
+// if it succeeded. Without Redis there is nothing to sync and it returns
+// nil.
+func (r *PostgresUserRepository) InitialSync() error {
+	res := r.initialSync.Load()
+	if res == nil {
+		return ErrSyncPending
+	}
+	return res.err
+}
+
+// SetTimeouts changes the deadlines of operations started from now on.
+func (r *PostgresUserRepository) SetTimeouts(timeouts Timeouts) {
+	r.timeouts.set(timeouts)
+}
+
+// Close waits for background work to finish writing to Redis, or for ctx
+// to be done, in which case the work is canceled. The connections are not
+// closed, since they are shared.
+func (r *PostgresUserRepository) Close(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.stopBackground()
+		return fmt.Errorf("background work still running: %w", ctx.Err())
+	}
+}
+
+// SyncToRedis implements UserRepository.
+func (r *PostgresUserRepository) SyncToRedis(ctx context.Context) error {
+	if r.rdb == nil {
+		return ErrRedisUnavailable
+	}
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Sync)
+	defer cancel()
+
 	var users []models.User
-	if err := r.db.Find(&users).Error; err != nil {
-		return err
+	if err := r.db.WithContext(ctx).Find(&users).Error; err != nil {
+		return dbError(err, nil)
 	}
 
-	ctx := context.Background()
 	pipe := r.rdb.Pipeline()
 
 	// Clear existing
@@ -70,43 +179,102 @@ func (r *PostgresUserRepository) SyncToRedis() error {
 		})
 	}
 
-	_, err := pipe.Exec(ctx)
-	return err
+	if _, err := pipe.Exec(ctx); err != nil {
+		return redisError(err)
+	}
+
+	r.announce(ctx, Change{Reset: true}, events.Event{Type: events.LeaderboardReset})
+	return nil
 }
 
 // Create implements UserRepository.
-func (r *PostgresUserRepository) Create(u *models.User) error {
-	if err := r.db.Create(&u).Error; err != nil {
-		return err
+func (r *PostgresUserRepository) Create(ctx context.Context, u *models.User) error {
+	writeCtx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+
+	if err := r.db.WithContext(writeCtx).Create(&u).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return ErrUsernameTaken
+		}
+		return dbError(err, nil)
 	}
+
+	// The user exists now: bring Redis and the change log up to date even
+	// if the caller gives up, or they would disagree with Postgres.
+	ctx = context.WithoutCancel(ctx)
 	if r.rdb != nil {
-		ctx := context.Background()
 		member := fmt.Sprintf("%s:%d", u.Username, u.ID)
 		r.rdb.ZAdd(ctx, LeaderboardKey, redis.Z{
 			Score:  float64(u.Rating),
 			Member: member,
 		})
 	}
+
+	r.announce(ctx, Change{
+		UserID:    u.ID,
+		Username:  u.Username,
+		NewRating: u.Rating,
+		Created:   true,
+	}, events.Event{
+		Type:      events.UserCreated,
+		UserID:    u.ID,
+		Username:  u.Username,
+		NewRating: u.Rating,
+	})
 	return nil
 }
 
 // GetByUsername implements UserRepository.
-func (r *PostgresUserRepository) GetByUsername(username string) (*models.User, error) {
+func (r *PostgresUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
 	var user models.User
-	err := r.db.Where("username LIKE ?", "%"+username+"%").First(&user).Error
-	return &user, err
+	err := r.db.WithContext(ctx).Where("username LIKE ?", "%"+username+"%").First(&user).Error
+	return &user, dbError(err, ErrUserNotFound)
 }
 
-// GetLeaderboard implements UserRepository.
-func (r *PostgresUserRepository) GetLeaderboard(limit int, offset int) ([]UserWithRank, error) {
-	if r.rdb == nil {
-		return r.getLeaderboardSQL(limit, offset)
+// GetUserWithRankByID implements UserRepository.
+func (r *PostgresUserRepository) GetUserWithRankByID(ctx context.Context, userID int) (*UserWithRank, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, userID).Error; err != nil {
+		return nil, dbError(err, ErrUserNotFound)
+	}
+
+	rank, err := withFallback(r, "user_rank",
+		func() (int, error) {
+			count, err := r.rdb.ZCount(ctx, LeaderboardKey, "("+strconv.Itoa(user.Rating), "+inf").Result()
+			return int(count) + 1, err
+		},
+		func() (int, error) { return r.getUserWithRankSQL(ctx, &user) },
+	)
+	if err != nil {
+		return nil, err
 	}
+	return &UserWithRank{User: user, Rank: rank}, nil
+}
+
+// GetLeaderboard implements UserRepository.
+func (r *PostgresUserRepository) GetLeaderboard(ctx context.Context, limit int, offset int) ([]UserWithRank, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	return withFallback(r, "leaderboard",
+		func() ([]UserWithRank, error) { return r.getLeaderboardRedis(ctx, limit, offset) },
+		func() ([]UserWithRank, error) { return r.getLeaderboardSQL(ctx, limit, offset) },
+	)
+}
 
-	ctx := context.Background()
+func (r *PostgresUserRepository) getLeaderboardRedis(ctx context.Context, limit int, offset int) ([]UserWithRank, error) {
 	// 1. Fetch Top N members from Redis
 	res, err := r.rdb.ZRevRangeWithScores(ctx, LeaderboardKey, int64(offset), int64(offset+limit-1)).Result()
-	if err != nil || len(res) == 0 {
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
 		return []UserWithRank{}, nil
 	}
 
@@ -120,7 +288,9 @@ func (r *PostgresUserRepository) GetLeaderboard(limit int, offset int) ([]UserWi
 			uniqueScores[z.Score] = pipe.ZCount(ctx, LeaderboardKey, "("+strconv.FormatFloat(z.Score, 'f', -1, 64), "+inf")
 		}
 	}
-	_, _ = pipe.Exec(ctx)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
 
 	// 3. Assemble Final Response without any DB or extra Redis hits
 	userWithRanks := make([]UserWithRank, 0, len(res))
@@ -150,7 +320,7 @@ func (r *PostgresUserRepository) GetLeaderboard(limit int, offset int) ([]UserWi
 	return userWithRanks, nil
 }
 
-func (r *PostgresUserRepository) getLeaderboardSQL(limit int, offset int) ([]UserWithRank, error) {
+func (r *PostgresUserRepository) getLeaderboardSQL(ctx context.Context, limit int, offset int) ([]UserWithRank, error) {
 	var users []UserWithRank
 	query := `
 		SELECT *, RANK() OVER (ORDER BY rating DESC) as rank
@@ -158,69 +328,212 @@ func (r *PostgresUserRepository) getLeaderboardSQL(limit int, offset int) ([]Use
 		ORDER BY rating DESC
 		LIMIT ? OFFSET ?
 	`
-	err := r.db.Raw(query, limit, offset).Scan(&users).Error
-	return users, err
+	err := r.db.WithContext(ctx).Raw(query, limit, offset).Scan(&users).Error
+	return users, dbError(err, nil)
 }
 
 // SearchUsersWithRank implements UserRepository.
-func (r *PostgresUserRepository) SearchUsersWithRank(query string) ([]UserWithRank, error) {
+func (r *PostgresUserRepository) SearchUsersWithRank(ctx context.Context, query string) ([]UserWithRank, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Search)
+	defer cancel()
+
 	var users []models.User
-	err := r.db.Where("username LIKE ?", "%"+query+"%").
+	err := r.db.WithContext(ctx).Where("username LIKE ?", "%"+query+"%").
 		Order("rating DESC").
 		Limit(10).
 		Find(&users).Error
 	if err != nil {
-		return nil, err
+		return nil, dbError(err, nil)
 	}
 
-	results := make([]UserWithRank, 0, len(users))
-	ctx := context.Background()
+	return withFallback(r, "search",
+		func() ([]UserWithRank, error) {
+			pipe := r.rdb.Pipeline()
+			rankCmds := make([]*redis.IntCmd, len(users))
+			for i, u := range users {
+				rankCmds[i] = pipe.ZCount(ctx, LeaderboardKey, "("+strconv.Itoa(u.Rating), "+inf")
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return nil, err
+			}
 
-	if r.rdb == nil {
-		for _, u := range users {
-			rank, _ := r.getUserWithRankSQL(&u)
-			results = append(results, UserWithRank{User: u, Rank: rank})
-		}
-		return results, nil
-	}
+			results := make([]UserWithRank, 0, len(users))
+			for i, u := range users {
+				count, _ := rankCmds[i].Result()
+				results = append(results, UserWithRank{
+					User: u,
+					Rank: int(count) + 1,
+				})
+			}
+			return results, nil
+		},
+		func() ([]UserWithRank, error) {
+			results := make([]UserWithRank, 0, len(users))
+			for _, u := range users {
+				rank, err := r.getUserWithRankSQL(ctx, &u)
+				if contextError(err) != nil {
+					return nil, contextError(err)
+				}
+				results = append(results, UserWithRank{User: u, Rank: rank})
+			}
+			return results, nil
+		},
+	)
+}
 
-	pipe := r.rdb.Pipeline()
-	rankCmds := make([]*redis.IntCmd, len(users))
-	for i, u := range users {
-		rankCmds[i] = pipe.ZCount(ctx, LeaderboardKey, "("+strconv.Itoa(u.Rating), "+inf")
+// Size returns the number of users on the leaderboard.
+func (r *PostgresUserRepository) Size(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	return withFallback(r, "size",
+		func() (int64, error) { return r.rdb.ZCard(ctx, LeaderboardKey).Result() },
+		func() (int64, error) {
+			var n int64
+			err := r.db.WithContext(ctx).Model(&models.User{}).Count(&n).Error
+			return n, dbError(err, nil)
+		},
+	)
+}
+
+// CountRatedAbove implements UserRepository.
+func (r *PostgresUserRepository) CountRatedAbove(ctx context.Context, ratings []int) ([]int64, error) {
+	if len(ratings) == 0 {
+		return []int64{}, nil
 	}
-	_, _ = pipe.Exec(ctx)
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	return withFallback(r, "count_above",
+		func() ([]int64, error) {
+			pipe := r.rdb.Pipeline()
+			cmds := make([]*redis.IntCmd, len(ratings))
+			for i, rating := range ratings {
+				cmds[i] = pipe.ZCount(ctx, LeaderboardKey, "("+strconv.Itoa(rating), "+inf")
+			}
+			if _, err := pipe.Exec(ctx); err != nil {
+				return nil, redisError(err)
+			}
+			counts := make([]int64, len(ratings))
+			for i, cmd := range cmds {
+				counts[i] = cmd.Val()
+			}
+			return counts, nil
+		},
+		func() ([]int64, error) {
+			// One query for all ratings, each counted with the rating index.
+			values := make([]string, len(ratings))
+			args := make([]any, len(ratings))
+			for i, rating := range ratings {
+				values[i] = fmt.Sprintf("(%d, ?::int)", i)
+				args[i] = rating
+			}
+			var rows []struct {
+				I     int
+				Count int64
+			}
+			err := r.db.WithContext(ctx).Raw(
+				"SELECT v.i, (SELECT COUNT(*) FROM users WHERE rating > v.rating) AS count FROM (VALUES "+
+					strings.Join(values, ", ")+") AS v(i, rating)", args...).Scan(&rows).Error
+			if err != nil {
+				return nil, dbError(err, nil)
+			}
+			counts := make([]int64, len(ratings))
+			for _, row := range rows {
+				counts[row.I] = row.Count
+			}
+			return counts, nil
+		},
+	)
+}
 
-	for i, u := range users {
-		count, _ := rankCmds[i].Result()
-		results = append(results, UserWithRank{
-			User: u,
-			Rank: int(count) + 1,
-		})
+// ListRatings implements UserRepository. It reads every user, so it is
+// bounded by the sync timeout.
+func (r *PostgresUserRepository) ListRatings(ctx context.Context) ([]UserRating, error) {
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Sync)
+	defer cancel()
+
+	var ratings []UserRating
+	err := r.db.WithContext(ctx).Model(&models.User{}).Select("id", "rating").Order("id").Scan(&ratings).Error
+	return ratings, dbError(err, nil)
+}
+
+// withFallback answers the read op from Redis, or from Postgres when
+// running without Redis or when Redis fails, since Postgres holds the same
+// data. Both are counted in the read metrics.
+func withFallback[T any](r *PostgresUserRepository, op string, fromRedis, fromSQL func() (T, error)) (T, error) {
+	if r.rdb != nil {
+		v, err := fromRedis()
+		if err == nil {
+			metrics.LeaderboardReads.WithLabelValues(op, "redis").Inc()
+			return v, nil
+		}
+		if ctxErr := contextError(err); ctxErr != nil {
+			var zero T
+			return zero, ctxErr
+		}
+		metrics.RedisFallbacks.WithLabelValues(op).Inc()
 	}
 
-	return results, nil
+	v, err := fromSQL()
+	if err == nil {
+		metrics.LeaderboardReads.WithLabelValues(op, "sql").Inc()
+	}
+	return v, err
 }
 
-func (r *PostgresUserRepository) getUserWithRankSQL(user *models.User) (int, error) {
+func (r *PostgresUserRepository) getUserWithRankSQL(ctx context.Context, user *models.User) (int, error) {
 	var rank int
-	err := r.db.Raw("SELECT rank FROM (SELECT id, RANK() OVER (ORDER BY rating DESC) as rank FROM users) s WHERE id = ?", user.ID).Scan(&rank).Error
-	return rank, err
+	err := r.db.WithContext(ctx).Raw("SELECT rank FROM (SELECT id, RANK() OVER (ORDER BY rating DESC) as rank FROM users) s WHERE id = ?", user.ID).Scan(&rank).Error
+	return rank, dbError(err, nil)
 }
 
 // UpdateRating implements UserRepository.
-func (r *PostgresUserRepository) UpdateRating(userID int, newRating int) error {
+func (r *PostgresUserRepository) UpdateRating(ctx context.Context, userID int, newRating int, ifVersion int) (*models.User, error) {
+	writeCtx, cancel := withTimeout(ctx, r.timeouts.get().Write)
+	defer cancel()
+	db := r.db.WithContext(writeCtx)
+
 	var user models.User
-	if err := r.db.First(&user, userID).Error; err != nil {
-		return err
-	}
+	var oldRating int
+	for {
+		if err := db.First(&user, userID).Error; err != nil {
+			return nil, dbError(err, ErrUserNotFound)
+		}
+		if ifVersion != 0 && user.Version != ifVersion {
+			return nil, ErrVersionConflict
+		}
 
-	if err := r.db.Model(&user).Update("rating", newRating).Error; err != nil {
-		return err
+		// Compare and swap on the version, so the old rating reported in
+		// the change is the one actually replaced.
+		oldRating = user.Rating
+		now := time.Now()
+		res := db.Model(&models.User{}).
+			Where("id = ? AND version = ?", user.ID, user.Version).
+			Updates(map[string]any{
+				"rating":     newRating,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": now,
+			})
+		if res.Error != nil {
+			return nil, dbError(res.Error, nil)
+		}
+		if res.RowsAffected == 1 {
+			user.UpdatedAt = now
+			break
+		}
+		if ifVersion != 0 {
+			return nil, ErrVersionConflict
+		}
+		// Lost a race with another unconditional write; read it and retry.
 	}
 
+	user.Rating = newRating
+	user.Version++
+
+	// The rating is committed; see Create.
+	ctx = context.WithoutCancel(ctx)
 	if r.rdb != nil {
-		ctx := context.Background()
 		member := fmt.Sprintf("%s:%d", user.Username, user.ID)
 		r.rdb.ZAdd(ctx, LeaderboardKey, redis.Z{
 			Score:  float64(newRating),
@@ -228,5 +541,31 @@ func (r *PostgresUserRepository) UpdateRating(userID int, newRating int) error {
 		})
 	}
 
-	return nil
+	r.announce(ctx, Change{
+		UserID:    user.ID,
+		Username:  user.Username,
+		OldRating: oldRating,
+		NewRating: newRating,
+	}, events.Event{
+		Type:      events.RatingUpdated,
+		UserID:    user.ID,
+		Username:  user.Username,
+		OldRating: oldRating,
+		NewRating: newRating,
+	})
+
+	return &user, nil
+}
+
+// publish notifies subscribers on every instance about a committed write.
+// A failure here never fails the write itself: Postgres stays the source
+// of truth and subscribers can always fall back to a full refresh.
+func (r *PostgresUserRepository) publish(ctx context.Context, e events.Event) {
+	if r.bus == nil {
+		return
+	}
+	e.At = time.Now()
+	if err := r.bus.Publish(ctx, e); err != nil {
+		logger.ErrorContext(ctx, "Failed to publish leaderboard event", "type", e.Type, "user_id", e.UserID, "error", err)
+	}
 }
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"leaderboard/internal/events"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// LeaderboardVersionKey holds a counter that is incremented on every write
+// to the leaderboard, shared by all instances.
+const LeaderboardVersionKey = "global_leaderboard:version"
+
+// initialVersion is the version a leaderboard starts from: the clock, in
+// milliseconds, so versions handed out before a restart, or before Redis
+// lost the version key to a flush or eviction, are never reused for
+// different data.
+func initialVersion() int64 {
+	return time.Now().UnixMilli()
+}
+
+// recordChangeScript bumps the version and appends the change to the log in
+// one step, so the log never has gaps and is always ordered by version.
+// Entries are stored as "<version>:<json>". A missing version starts from
+// ARGV[3].
+var recordChangeScript = redis.NewScript(`
+redis.call('SET', KEYS[1], ARGV[3], 'NX')
+local v = redis.call('INCR', KEYS[1])
+redis.call('LPUSH', KEYS[2], v .. ':' .. ARGV[1])
+redis.call('LTRIM', KEYS[2], 0, tonumber(ARGV[2]) - 1)
+return v
+`)
+
+// GetVersion implements UserRepository.
+func (r *PostgresUserRepository) GetVersion(ctx context.Context) (int64, error) {
+	if r.rdb == nil {
+		return r.changes.currentVersion(), nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeouts.get().Read)
+	defer cancel()
+
+	if err := r.recordLostChange(ctx); err != nil {
+		return 0, err
+	}
+	v, err := r.rdb.Get(ctx, LeaderboardVersionKey).Int64()
+	if errors.Is(err, redis.Nil) {
+		if err := r.rdb.SetNX(ctx, LeaderboardVersionKey, initialVersion(), 0).Err(); err != nil {
+			return 0, redisError(err)
+		}
+		v, err = r.rdb.Get(ctx, LeaderboardVersionKey).Int64()
+	}
+	return v, redisError(err)
+}
+
+// announce records the change c of a committed write and publishes e with
+// the version it brought the leaderboard to. When the change can't be
+// recorded, a reset is published instead, so subscribers start over
+// rather than miss it.
+func (r *PostgresUserRepository) announce(ctx context.Context, c Change, e events.Event) {
+	v, err := r.recordChange(ctx, c)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to record leaderboard change", "user_id", c.UserID, "error", err)
+		e = events.Event{Type: events.LeaderboardReset}
+	}
+	e.Version = v
+	r.publish(ctx, e)
+}
+
+// recordChange advances the leaderboard version after a committed write,
+// appends the change to the change log and returns the new version.
+// Without Redis both live in this process only.
+//
+// A change that can't be recorded leaves the version where it was,
+// although the leaderboard changed, so the next change recorded is made a
+// reset: clients at an earlier version start over instead of taking the
+// lost change for no change.
+func (r *PostgresUserRepository) recordChange(ctx context.Context, c Change) (int64, error) {
+	if r.rdb == nil {
+		return r.changes.append(c), nil
+	}
+
+	if r.changeLost.Swap(false) {
+		c.Reset = true
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		r.changeLost.Store(true)
+		return 0, err
+	}
+
+	keys := []string{LeaderboardVersionKey, LeaderboardChangesKey}
+	v, err := recordChangeScript.Run(ctx, r.rdb, keys, payload, ChangeLogSize, initialVersion()).Int64()
+	if err != nil {
+		r.changeLost.Store(true)
+		return 0, redisError(err)
+	}
+	return v, nil
+}
+
+// recordLostChange records the reset owed for a change that couldn't be
+// recorded, if any, before the version is read, so readers don't wait for
+// the next write to learn about it.
+func (r *PostgresUserRepository) recordLostChange(ctx context.Context) error {
+	if !r.changeLost.Load() {
+		return nil
+	}
+	_, err := r.recordChange(ctx, Change{Reset: true})
+	return err
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"leaderboard/internal/auth"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/models"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/tracing"
+)
+
+var auditLog = logging.Logger("audit")
+
+// MaxAuditPageSize bounds the entries listed at once.
+const MaxAuditPageSize = 200
+
+// AuditService keeps a record of operations actions, in Postgres and in
+// the logs.
+type AuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditService(auditRepo repository.AuditRepository) *AuditService {
+	return &AuditService{auditRepo: auditRepo}
+}
+
+// Record records that the caller authenticated in ctx performed action,
+// with the given outcome and details, a JSON document or nil.
+func (s *AuditService) Record(ctx context.Context, action string, status int, details []byte) (err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.Record")
+	defer tracing.End(span, &err)
+
+	entry := &models.AuditEntry{
+		Action:    action,
+		Status:    status,
+		RequestID: logging.RequestID(ctx),
+		Details:   details,
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		entry.Actor = p.Name
+		if p.KeyID != 0 {
+			entry.KeyID = &p.KeyID
+		}
+	}
+
+	auditLog.InfoContext(ctx, "Admin action", "action", action, "actor", entry.Actor, "status", status)
+	return s.auditRepo.Record(ctx, entry)
+}
+
+// List returns up to limit entries, newest first, older than the entry
+// with ID before, or the newest ones when before is 0.
+func (s *AuditService) List(ctx context.Context, limit int, before int64) (_ []models.AuditEntry, err error) {
+	ctx, span := tracer.Start(ctx, "AuditService.List")
+	defer tracing.End(span, &err)
+
+	return s.auditRepo.List(ctx, min(max(limit, 1), MaxAuditPageSize), before)
+}
@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/tracing"
+	"slices"
+)
+
+// LeaderboardDelta describes how a range of the leaderboard changed between
+// two versions.
+type LeaderboardDelta struct {
+	Version int64 `json:"version"`
+	Since   int64 `json:"since"`
+	// FullRefresh tells the client that the changes can't be computed
+	// incrementally and the range must be downloaded again.
+	FullRefresh bool `json:"full_refresh"`
+	// Changes holds the users in the range whose rank or rating changed,
+	// with their current rank and rating.
+	Changes []repository.UserWithRank `json:"changes"`
+	// Removed holds users that were in the range at Since and no longer
+	// are. Clients drop them, then sort by rank and trim to the range size.
+	Removed []int `json:"removed"`
+}
+
+// netChange is the combined effect of all changes to one user.
+type netChange struct {
+	existed   bool
+	oldRating int
+	newRating int
+}
+
+// GetChangesSince returns the users ranked start..stop (zero based,
+// inclusive) whose rank or rating changed after version since.
+func (s *LeaderboardService) GetChangesSince(ctx context.Context, since int64, start, stop int) (_ *LeaderboardDelta, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.GetChangesSince")
+	defer tracing.End(span, &err)
+
+	if start < 0 || stop < start {
+		return nil, apperr.Validation("range_invalid", "invalid range")
+	}
+	if stop-start+1 > MaxPageSize {
+		return nil, apperr.Validation("range_too_large", fmt.Sprintf("range cannot span more than %d ranks", MaxPageSize))
+	}
+
+	changes, version, err := s.userRepo.GetChangesSince(ctx, since)
+	// An entry that can't be read is as good as gone, as long as the
+	// current version is known.
+	if errors.Is(err, repository.ErrChangeLogTruncated) || (errors.Is(err, repository.ErrChangeLogCorrupt) && version != 0) {
+		return &LeaderboardDelta{Version: version, Since: since, FullRefresh: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	delta := &LeaderboardDelta{
+		Version: version,
+		Since:   since,
+		Changes: []repository.UserWithRank{},
+		Removed: []int{},
+	}
+	if len(changes) == 0 {
+		return delta, nil
+	}
+
+	net := make(map[int]*netChange)
+	for _, c := range changes {
+		if c.Reset {
+			delta.FullRefresh = true
+			return delta, nil
+		}
+		if n, ok := net[c.UserID]; ok {
+			n.newRating = c.NewRating
+			continue
+		}
+		net[c.UserID] = &netChange{existed: !c.Created, oldRating: c.OldRating, newRating: c.NewRating}
+	}
+
+	users, err := s.userRepo.GetLeaderboard(ctx, stop-start+1, start)
+	if err != nil {
+		return nil, err
+	}
+
+	inRange := make(map[int]bool, len(users))
+	for _, u := range users {
+		inRange[u.ID] = true
+
+		if n, ok := net[u.ID]; ok {
+			if !n.existed || n.oldRating != n.newRating {
+				delta.Changes = append(delta.Changes, u)
+				continue
+			}
+		}
+		if rankShift(net, u.ID, u.Rating) != 0 {
+			delta.Changes = append(delta.Changes, u)
+		}
+	}
+
+	removed, err := s.leftRange(ctx, net, inRange, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	delta.Removed = removed
+	return delta, nil
+}
+
+// leftRange returns the users that changed, were ranked start..stop
+// before their changes and no longer are. Users that stay out of the range
+// are left out, so a delta only grows with changes to the range.
+//
+// A user was in the range if, among the users rated higher or the same at
+// the time, some position in start..stop was theirs. Those counts are
+// today's counts with the changes undone.
+func (s *LeaderboardService) leftRange(ctx context.Context, net map[int]*netChange, inRange map[int]bool, start, stop int) ([]int, error) {
+	var candidates []int
+	var ratings []int
+	for id, n := range net {
+		if n.existed && !inRange[id] {
+			candidates = append(candidates, id)
+			// Ratings are integers, so "rated higher or the same" is rated
+			// strictly higher than one less.
+			ratings = append(ratings, n.oldRating, n.oldRating-1)
+		}
+	}
+	removed := []int{}
+	if len(candidates) == 0 {
+		return removed, nil
+	}
+
+	counts, err := s.userRepo.CountRatedAbove(ctx, ratings)
+	if err != nil {
+		return nil, err
+	}
+
+	var newRatings, oldRatings []int
+	for _, n := range net {
+		newRatings = append(newRatings, n.newRating)
+		if n.existed {
+			oldRatings = append(oldRatings, n.oldRating)
+		}
+	}
+	slices.Sort(newRatings)
+	slices.Sort(oldRatings)
+	// ratedAboveBefore counts the users rated higher than rating before
+	// the changes.
+	ratedAboveBefore := func(now int64, rating int) int64 {
+		return now - countAbove(newRatings, rating) + countAbove(oldRatings, rating)
+	}
+
+	for i, id := range candidates {
+		rating := net[id].oldRating
+		higher := ratedAboveBefore(counts[2*i], rating)
+		// The user is among those rated the same, so isn't counted.
+		higherOrSame := ratedAboveBefore(counts[2*i+1], rating-1) - 1
+		if higher <= int64(stop) && higherOrSame >= int64(start) {
+			removed = append(removed, id)
+		}
+	}
+	return removed, nil
+}
+
+// countAbove returns how many of the sorted ratings are above rating.
+func countAbove(sorted []int, rating int) int64 {
+	i, _ := slices.BinarySearch(sorted, rating+1)
+	return int64(len(sorted) - i)
+}
+
+// rankShift returns how much the rank of user id with the given (unchanged)
+// rating moved because of other users' changes. A user's rank is one plus
+// the number of users rated strictly higher, so only changes that cross the
+// rating matter.
+func rankShift(net map[int]*netChange, id, rating int) int {
+	shift := 0
+	for otherID, n := range net {
+		if otherID == id {
+			continue
+		}
+		if n.existed && n.oldRating > rating {
+			shift--
+		}
+		if n.newRating > rating {
+			shift++
+		}
+	}
+	return shift
+}
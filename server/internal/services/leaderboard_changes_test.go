@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"leaderboard/internal/models"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/username"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// fakeUserRepository keeps users in memory and serves a fixed change log.
+// Methods the tests don't need panic through the nil embedded interface.
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	mu      sync.Mutex
+	users   map[int]int // rating by user ID
+	version int64
+	changes []repository.Change
+	// changesErr is returned by GetChangesSince along with version.
+	changesErr error
+}
+
+func newFakeUserRepository(ratings map[int]int) *fakeUserRepository {
+	return &fakeUserRepository{users: ratings, version: 1}
+}
+
+// set changes the rating of user id, recording the change.
+func (r *fakeUserRepository) set(id, rating int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, existed := r.users[id]
+	r.version++
+	r.changes = append(r.changes, repository.Change{Version: r.version, UserID: id, OldRating: old, NewRating: rating, Created: !existed})
+	r.users[id] = rating
+}
+
+func (r *fakeUserRepository) UpdateRating(ctx context.Context, userID int, newRating int, ifVersion int) (*models.User, error) {
+	r.set(userID, newRating)
+	return &models.User{ID: userID, Rating: newRating}, nil
+}
+
+func (r *fakeUserRepository) ListRatings(ctx context.Context) ([]repository.UserRating, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]repository.UserRating, 0, len(r.users))
+	for id, rating := range r.users {
+		users = append(users, repository.UserRating{ID: id, Rating: rating})
+	}
+	slices.SortFunc(users, func(a, b repository.UserRating) int { return a.ID - b.ID })
+	return users, nil
+}
+
+func (r *fakeUserRepository) GetLeaderboard(ctx context.Context, limit, offset int) ([]repository.UserWithRank, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var users []repository.UserWithRank
+	for id, rating := range r.users {
+		users = append(users, repository.UserWithRank{User: models.User{ID: id, Rating: rating}})
+	}
+	slices.SortFunc(users, func(a, b repository.UserWithRank) int {
+		if a.Rating != b.Rating {
+			return b.Rating - a.Rating
+		}
+		return a.ID - b.ID
+	})
+	for i := range users {
+		users[i].Rank = 1 + int(r.countAbove(users[i].Rating))
+	}
+	if offset >= len(users) {
+		return []repository.UserWithRank{}, nil
+	}
+	return users[offset:min(offset+limit, len(users))], nil
+}
+
+func (r *fakeUserRepository) countAbove(rating int) int64 {
+	var n int64
+	for _, other := range r.users {
+		if other > rating {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *fakeUserRepository) CountRatedAbove(ctx context.Context, ratings []int) ([]int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make([]int64, len(ratings))
+	for i, rating := range ratings {
+		counts[i] = r.countAbove(rating)
+	}
+	return counts, nil
+}
+
+func (r *fakeUserRepository) GetVersion(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.version, nil
+}
+
+func (r *fakeUserRepository) GetChangesSince(ctx context.Context, version int64) ([]repository.Change, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.changesErr != nil {
+		return nil, r.version, r.changesErr
+	}
+	var changes []repository.Change
+	for _, c := range r.changes {
+		if c.Version > version {
+			changes = append(changes, c)
+		}
+	}
+	return changes, r.version, nil
+}
+
+func TestGetChangesSince(t *testing.T) {
+	// Users 1 to 5 are rated 100, 90, 80, 70 and 60, so user n is ranked n.
+	type rated struct{ id, rating int }
+	tests := []struct {
+		name        string
+		updates     []rated
+		changesErr  error
+		reset       bool
+		start, stop int
+		// wantChanges are the {user ID, rank} pairs of the changes.
+		wantChanges [][2]int
+		wantRemoved []int
+		wantRefresh bool
+	}{
+		{
+			name:        "no changes",
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "move up",
+			updates:     []rated{{4, 95}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{{4, 2}, {2, 3}},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "move down",
+			updates:     []rated{{1, 75}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{{2, 1}, {3, 2}, {1, 3}},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "enter the page",
+			updates:     []rated{{5, 85}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{{5, 3}},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "new user enters the page",
+			updates:     []rated{{6, 95}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{{6, 2}, {2, 3}},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "leave the page downwards",
+			updates:     []rated{{2, 50}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{{3, 2}, {4, 3}},
+			wantRemoved: []int{2},
+		},
+		{
+			name:        "leave the page upwards",
+			updates:     []rated{{4, 95}},
+			start:       3,
+			stop:        4,
+			wantChanges: [][2]int{{3, 4}},
+			wantRemoved: []int{4},
+		},
+		{
+			name:        "leave and come back",
+			updates:     []rated{{2, 50}, {2, 90}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "changes outside the page",
+			updates:     []rated{{5, 65}, {4, 50}},
+			start:       0,
+			stop:        2,
+			wantChanges: [][2]int{},
+			wantRemoved: []int{},
+		},
+		{
+			name:        "gap in the change log",
+			updates:     []rated{{4, 95}},
+			changesErr:  repository.ErrChangeLogTruncated,
+			start:       0,
+			stop:        2,
+			wantRefresh: true,
+		},
+		{
+			name:        "unreadable change log",
+			updates:     []rated{{4, 95}},
+			changesErr:  repository.ErrChangeLogCorrupt,
+			start:       0,
+			stop:        2,
+			wantRefresh: true,
+		},
+		{
+			name:        "reset",
+			updates:     []rated{{4, 95}},
+			reset:       true,
+			start:       0,
+			stop:        2,
+			wantRefresh: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepository(map[int]int{1: 100, 2: 90, 3: 80, 4: 70, 5: 60})
+			since := repo.version
+			for _, u := range tt.updates {
+				repo.set(u.id, u.rating)
+			}
+			if tt.reset {
+				repo.version++
+				repo.changes = append(repo.changes, repository.Change{Version: repo.version, Reset: true})
+			}
+			repo.changesErr = tt.changesErr
+
+			s := NewLeaderboardService(repo, username.Policy{})
+			delta, err := s.GetChangesSince(context.Background(), since, tt.start, tt.stop)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if delta.Version != repo.version || delta.Since != since {
+				t.Errorf("delta from %d to %d, want from %d to %d", delta.Since, delta.Version, since, repo.version)
+			}
+			if delta.FullRefresh != tt.wantRefresh {
+				t.Fatalf("FullRefresh = %v, want %v", delta.FullRefresh, tt.wantRefresh)
+			}
+			if tt.wantRefresh {
+				return
+			}
+
+			changes := [][2]int{}
+			for _, u := range delta.Changes {
+				changes = append(changes, [2]int{u.ID, u.Rank})
+			}
+			if !slices.Equal(changes, tt.wantChanges) {
+				t.Errorf("changes = %v, want %v", changes, tt.wantChanges)
+			}
+			slices.Sort(delta.Removed)
+			if !slices.Equal(delta.Removed, tt.wantRemoved) {
+				t.Errorf("removed = %v, want %v", delta.Removed, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestGetChangesSinceRange(t *testing.T) {
+	s := NewLeaderboardService(newFakeUserRepository(map[int]int{}), username.Policy{})
+	for _, r := range [][2]int{{-1, 2}, {3, 2}, {0, MaxPageSize}} {
+		if _, err := s.GetChangesSince(context.Background(), 1, r[0], r[1]); err == nil {
+			t.Errorf("range %d..%d accepted", r[0], r[1])
+		}
+	}
+}
+
+func TestRankShift(t *testing.T) {
+	net := map[int]*netChange{
+		1: {existed: true, oldRating: 100, newRating: 50},
+		2: {existed: false, newRating: 85},
+		3: {existed: true, oldRating: 70, newRating: 95},
+	}
+
+	tests := []struct {
+		id, rating int
+		want       int
+	}{
+		// User 1 fell below, users 2 and 3 rose above.
+		{id: 4, rating: 80, want: 1},
+		// Only the new user 2 adds to those above; users 1 and 3 stayed.
+		{id: 4, rating: 40, want: 1},
+		// User 1 fell below and user 3 rose above.
+		{id: 4, rating: 90, want: 0},
+		// Nobody crossed 200.
+		{id: 4, rating: 200, want: 0},
+		// A user's own change doesn't count.
+		{id: 3, rating: 95, want: -1},
+	}
+	for _, tt := range tests {
+		if got := rankShift(net, tt.id, tt.rating); got != tt.want {
+			t.Errorf("rankShift(user %d, %d) = %d, want %d", tt.id, tt.rating, got, tt.want)
+		}
+	}
+}
+
+func TestCountAbove(t *testing.T) {
+	sorted := []int{10, 20, 20, 30}
+	tests := []struct {
+		rating int
+		want   int64
+	}{
+		{rating: 5, want: 4},
+		{rating: 10, want: 3},
+		{rating: 19, want: 3},
+		{rating: 20, want: 1},
+		{rating: 30, want: 0},
+	}
+	for _, tt := range tests {
+		if got := countAbove(sorted, tt.rating); got != tt.want {
+			t.Errorf("countAbove(%d) = %d, want %d", tt.rating, got, tt.want)
+		}
+	}
+}
@@ -1,63 +1,151 @@
 package services
 
 import (
-	"errors"
+	"context"
+	"fmt"
+	"leaderboard/internal/apperr"
 	"leaderboard/internal/models"
 	"leaderboard/internal/repository"
+	"leaderboard/internal/tracing"
+	"leaderboard/internal/username"
+	"math"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("leaderboard/internal/services")
+
+// MaxPageSize is the largest number of leaderboard entries returned at once.
+const MaxPageSize = 100
+
+// MaxRating is the highest rating the users table can hold.
+const MaxRating = math.MaxInt32
+
+var (
+	ErrUsernameRequired = apperr.Validation("username_required", "username is required")
+	ErrNegativeRating   = apperr.Validation("rating_negative", "rating cannot be negative")
+	ErrRatingTooHigh    = apperr.Validation("rating_too_high", fmt.Sprintf("rating cannot be above %d", MaxRating))
+	ErrInvalidLimit     = apperr.Validation("limit_invalid", "limit must be greater than 0")
 )
 
 type LeaderboardService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	usernames username.Policy
 }
 
-func NewLeaderboardService(userRepo repository.UserRepository) *LeaderboardService {
-	return &LeaderboardService{userRepo: userRepo}
+func NewLeaderboardService(userRepo repository.UserRepository, usernames username.Policy) *LeaderboardService {
+	return &LeaderboardService{userRepo: userRepo, usernames: usernames}
 }
 
-func (s *LeaderboardService) CreateUser(username string, rating int) (*models.User, error) {
-	if username == "" {
-		return nil, errors.New("username is required")
+func (s *LeaderboardService) CreateUser(ctx context.Context, name string, rating int) (_ *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.CreateUser")
+	defer tracing.End(span, &err)
+
+	if name == "" {
+		return nil, ErrUsernameRequired
 	}
 
 	if rating < 0 {
-		return nil, errors.New("rating cannot be negative")
+		return nil, ErrNegativeRating
+	}
+	if rating > MaxRating {
+		return nil, ErrRatingTooHigh
+	}
+
+	display, canonical, err := s.usernames.Normalize(name)
+	if err != nil {
+		return nil, err
 	}
 
 	user := &models.User{
-		Username: username,
-		Rating:   rating,
+		Username:           display,
+		UsernameNormalized: canonical,
+		Rating:             rating,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
 	return user, nil
 }
 
-func (s *LeaderboardService) UpdateRating(userId, newRating int) error {
+// UpdateRating sets a user's rating. A non-zero ifVersion makes it fail
+// with repository.ErrVersionConflict if the user changed since that version.
+func (s *LeaderboardService) UpdateRating(ctx context.Context, userId, newRating, ifVersion int) (_ *models.User, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.UpdateRating")
+	defer tracing.End(span, &err)
+
 	if newRating < 0 {
-		return errors.New("rating cannot be negative")
+		return nil, ErrNegativeRating
+	}
+	if newRating > MaxRating {
+		return nil, ErrRatingTooHigh
 	}
 
-	return s.userRepo.UpdateRating(userId, newRating)
+	return s.userRepo.UpdateRating(ctx, userId, newRating, ifVersion)
 }
 
-func (s *LeaderboardService) GetLeaderboard(limit, offset int) ([]repository.UserWithRank, error) {
+func (s *LeaderboardService) GetLeaderboard(ctx context.Context, limit, offset int) (_ []repository.UserWithRank, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.GetLeaderboard")
+	defer tracing.End(span, &err)
+
 	if limit <= 0 {
-		return nil, errors.New("limit must be greater than 0")
+		return nil, ErrInvalidLimit
 	}
 
-	if limit > 100 {
-		limit = 100
+	if limit > MaxPageSize {
+		limit = MaxPageSize
 	}
 
-	return s.userRepo.GetLeaderboard(limit, offset)
+	return s.userRepo.GetLeaderboard(ctx, limit, offset)
+}
+
+// LeaderboardVersion returns the version of the leaderboard, which changes
+// whenever any rating changes or a user is added.
+func (s *LeaderboardService) LeaderboardVersion(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.LeaderboardVersion")
+	defer tracing.End(span, &err)
+
+	return s.userRepo.GetVersion(ctx)
 }
 
-func (s *LeaderboardService) SearchUsers(username string) ([]repository.UserWithRank, error) {
+func (s *LeaderboardService) SearchUsers(ctx context.Context, username string) (_ []repository.UserWithRank, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.SearchUsers")
+	defer tracing.End(span, &err)
+
 	if username == "" {
-		return nil, errors.New("username is required")
+		return nil, ErrUsernameRequired
+	}
+	return s.userRepo.SearchUsersWithRank(ctx, username)
+}
+
+func (s *LeaderboardService) GetUserWithRank(ctx context.Context, userId int) (_ *repository.UserWithRank, err error) {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.GetUserWithRank")
+	defer tracing.End(span, &err)
+
+	return s.userRepo.GetUserWithRankByID(ctx, userId)
+}
+
+// RatingUpdate is one entry of a batch rating update.
+type RatingUpdate struct {
+	UserID int
+	Rating int
+}
+
+// UpdateRatings applies each update independently and returns one error
+// per update, nil for those that succeeded. Once ctx is done, the updates
+// not yet applied fail with its error.
+func (s *LeaderboardService) UpdateRatings(ctx context.Context, updates []RatingUpdate) []error {
+	ctx, span := tracer.Start(ctx, "LeaderboardService.UpdateRatings",
+		trace.WithAttributes(attribute.Int("updates", len(updates))))
+	defer span.End()
+
+	errs := make([]error, len(updates))
+	for i, u := range updates {
+		_, errs[i] = s.UpdateRating(ctx, u.UserID, u.Rating, 0)
 	}
-	return s.userRepo.SearchUsersWithRank(username)
+	return errs
 }
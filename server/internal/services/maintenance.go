@@ -0,0 +1,60 @@
+package services
+
+import (
+	"leaderboard/internal/apperr"
+	"sync/atomic"
+	"time"
+)
+
+// defaultMaintenanceMessage is shown to clients when maintenance is turned
+// on without a message.
+const defaultMaintenanceMessage = "the leaderboard is under maintenance, try again later"
+
+// MaintenanceStatus tells whether writes are turned away.
+type MaintenanceStatus struct {
+	Enabled bool
+	// Message is returned to clients whose writes are turned away.
+	Message string
+	// Since is when the status last changed.
+	Since time.Time
+}
+
+// Maintenance turns writes away while an operator works on the data, such
+// as during a reconciliation or a restore. Reads are still served. The
+// mode belongs to this instance, like the simulation.
+type Maintenance struct {
+	status atomic.Pointer[MaintenanceStatus]
+}
+
+func NewMaintenance() *Maintenance {
+	m := &Maintenance{}
+	m.status.Store(&MaintenanceStatus{Since: time.Now()})
+	return m
+}
+
+// Set turns maintenance mode on or off and returns the new status.
+func (m *Maintenance) Set(enabled bool, message string) MaintenanceStatus {
+	status := MaintenanceStatus{Enabled: enabled, Since: time.Now()}
+	if enabled {
+		status.Message = message
+		if status.Message == "" {
+			status.Message = defaultMaintenanceMessage
+		}
+	}
+	m.status.Store(&status)
+	return status
+}
+
+func (m *Maintenance) Status() MaintenanceStatus {
+	return *m.status.Load()
+}
+
+// Check returns an unavailable error carrying the maintenance message
+// while maintenance mode is on, nil otherwise.
+func (m *Maintenance) Check() error {
+	status := m.status.Load()
+	if !status.Enabled {
+		return nil
+	}
+	return apperr.Unavailable("maintenance", status.Message, nil)
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"leaderboard/internal/repository"
+	"leaderboard/internal/tracing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Stats describes the connections and caches of the server.
+type Stats struct {
+	Postgres sql.DBStats
+	// Redis is nil when running without Redis.
+	Redis *redis.PoolStats
+	// Cache is nil when the leaderboard cache is disabled.
+	Cache *repository.CacheStats
+}
+
+// OperationsService carries out maintenance tasks on the Redis leaderboard
+// and reports on the state of the server.
+type OperationsService struct {
+	userRepo *repository.PostgresUserRepository
+	// cache and rdb are nil when disabled.
+	cache *repository.CachedUserRepository
+	sqlDB *sql.DB
+	rdb   *redis.Client
+}
+
+func NewOperationsService(
+	userRepo *repository.PostgresUserRepository,
+	cache *repository.CachedUserRepository,
+	sqlDB *sql.DB,
+	rdb *redis.Client,
+) *OperationsService {
+	return &OperationsService{userRepo: userRepo, cache: cache, sqlDB: sqlDB, rdb: rdb}
+}
+
+// Resync rebuilds the Redis leaderboard from Postgres.
+func (s *OperationsService) Resync(ctx context.Context) (err error) {
+	ctx, span := tracer.Start(ctx, "OperationsService.Resync")
+	defer tracing.End(span, &err)
+
+	return s.userRepo.SyncToRedis(ctx)
+}
+
+// Reconcile corrects the members of the Redis leaderboard that differ from
+// Postgres, or only reports them with dryRun.
+func (s *OperationsService) Reconcile(ctx context.Context, dryRun bool) (_ *repository.ReconcileReport, err error) {
+	ctx, span := tracer.Start(ctx, "OperationsService.Reconcile")
+	defer tracing.End(span, &err)
+
+	return s.userRepo.Reconcile(ctx, dryRun)
+}
+
+func (s *OperationsService) Stats() Stats {
+	stats := Stats{Postgres: s.sqlDB.Stats()}
+	if s.rdb != nil {
+		stats.Redis = s.rdb.PoolStats()
+	}
+	if s.cache != nil {
+		cacheStats := s.cache.Stats()
+		stats.Cache = &cacheStats
+	}
+	return stats
+}
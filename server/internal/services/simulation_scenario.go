@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"leaderboard/internal/repository"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// scenario is a run of the simulation under one SimulationConfig: its
+// random numbers, its population and the ticks so far. Only the goroutine
+// running the simulation uses it.
+type scenario struct {
+	cfg  SimulationConfig
+	rng  *rand.Rand
+	zipf *rand.Zipf
+
+	// population holds the users updated, with their ratings as last set
+	// by the simulation.
+	population []repository.UserRating
+	drawn      bool
+	// drawnAt is the tick the population was drawn at.
+	drawnAt     int
+	ticks       int
+	warnedEmpty bool
+}
+
+func newScenario(cfg SimulationConfig) *scenario {
+	seed := cfg.Seed
+	for seed == 0 {
+		seed = rand.Int63()
+	}
+	sc := &scenario{cfg: cfg, rng: rand.New(rand.NewSource(seed))}
+	if cfg.Distribution == RatingZipf {
+		sc.zipf = rand.NewZipf(sc.rng, cfg.ZipfS, 1, uint64(cfg.MaxRating-cfg.MinRating))
+	}
+	// The seed is logged so a run with a random one can be played again.
+	simulationLog.Info("Simulation scenario started",
+		"seed", seed,
+		"distribution", cfg.Distribution,
+		"interval", cfg.Interval,
+		"updates_per_tick", cfg.UpdatesPerTick,
+		"population", cfg.Population,
+	)
+	return sc
+}
+
+// advance starts a tick and returns how many ratings to change in it:
+// UpdatesPerTick, times BurstFactor during a burst. Time is counted in
+// ticks, so bursts fall on the same ticks in every run.
+func (sc *scenario) advance() int {
+	sc.ticks++
+	if every := sc.cfg.BurstEvery; every > 0 {
+		elapsed := time.Duration(sc.ticks) * sc.cfg.Interval
+		if elapsed >= every && elapsed%every < sc.cfg.BurstDuration {
+			return sc.cfg.UpdatesPerTick * sc.cfg.BurstFactor
+		}
+	}
+	return sc.cfg.UpdatesPerTick
+}
+
+// needsPopulation reports whether the population must be drawn: at the
+// start, every PopulationRefresh, and while there are no users.
+func (sc *scenario) needsPopulation() bool {
+	if !sc.drawn || len(sc.population) == 0 {
+		return true
+	}
+	refresh := sc.cfg.PopulationRefresh
+	return refresh > 0 && time.Duration(sc.ticks-sc.drawnAt)*sc.cfg.Interval >= refresh
+}
+
+// drawPopulation draws Population users from the existing ones, or takes
+// them all.
+func (sc *scenario) drawPopulation(ctx context.Context, userRepo repository.UserRepository) error {
+	users, err := userRepo.ListRatings(ctx)
+	if err != nil {
+		return err
+	}
+	if n := sc.cfg.Population; n > 0 && n < len(users) {
+		// A partial Fisher-Yates shuffle, so the draw only depends on the
+		// seed and the users.
+		for i := range n {
+			j := i + sc.rng.Intn(len(users)-i)
+			users[i], users[j] = users[j], users[i]
+		}
+		users = users[:n]
+	}
+
+	sc.population = users
+	sc.drawn = true
+	sc.drawnAt = sc.ticks
+	if len(users) == 0 && !sc.warnedEmpty {
+		simulationLog.WarnContext(ctx, "Simulation has no users to update")
+	}
+	sc.warnedEmpty = len(users) == 0
+	return nil
+}
+
+// next picks a user of the population and their new rating.
+func (sc *scenario) next() (*repository.UserRating, int) {
+	u := &sc.population[sc.rng.Intn(len(sc.population))]
+	return u, sc.rating(u.Rating)
+}
+
+// rating picks a new rating, given the current one, from the
+// distribution of the scenario. Normal and random walk values falling
+// outside the rating range are clamped to it.
+func (sc *scenario) rating(current int) int {
+	cfg := sc.cfg
+	var r float64
+	switch cfg.Distribution {
+	case RatingNormal:
+		r = cfg.RatingMean + sc.rng.NormFloat64()*cfg.RatingStdDev
+	case RatingRandomWalk:
+		r = float64(current) + sc.rng.NormFloat64()*cfg.RatingStdDev
+	case RatingZipf:
+		return cfg.MinRating + int(sc.zipf.Uint64())
+	default:
+		return cfg.MinRating + sc.rng.Intn(cfg.MaxRating-cfg.MinRating+1)
+	}
+	return min(max(int(math.Round(r)), cfg.MinRating), cfg.MaxRating)
+}
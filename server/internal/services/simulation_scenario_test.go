@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// play runs ticks of cfg against a fresh population of 10 users and
+// returns the updates made, as {user ID, rating} pairs.
+func play(cfg SimulationConfig, ticks int) [][2]int {
+	users := make(map[int]int)
+	for id := 1; id <= 10; id++ {
+		users[id] = id * 100
+	}
+	repo := newFakeUserRepository(users)
+	s := NewSimulationService(repo, NewMaintenance(), cfg)
+	sc := newScenario(cfg)
+	for range ticks {
+		s.tick(context.Background(), sc)
+	}
+
+	var updates [][2]int
+	for _, c := range repo.changes {
+		updates = append(updates, [2]int{c.UserID, c.NewRating})
+	}
+	return updates
+}
+
+func TestScenarioSeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *SimulationConfig)
+	}{
+		{name: "uniform", change: func(c *SimulationConfig) {}},
+		{name: "normal", change: func(c *SimulationConfig) {
+			c.Distribution, c.RatingMean, c.RatingStdDev = RatingNormal, 500, 100
+		}},
+		{name: "random walk", change: func(c *SimulationConfig) {
+			c.Distribution, c.RatingStdDev = RatingRandomWalk, 50
+		}},
+		{name: "zipf", change: func(c *SimulationConfig) {
+			c.Distribution, c.ZipfS = RatingZipf, 1.5
+		}},
+		{name: "population", change: func(c *SimulationConfig) {
+			c.Population, c.PopulationRefresh = 3, 2*time.Second
+		}},
+		{name: "bursts", change: func(c *SimulationConfig) {
+			c.BurstEvery, c.BurstDuration, c.BurstFactor = 3*time.Second, time.Second, 4
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testScenario()
+			tt.change(&cfg)
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+
+			first := play(cfg, 6)
+			if again := play(cfg, 6); !slices.Equal(first, again) {
+				t.Errorf("seed %d played\n%v\nthen\n%v", cfg.Seed, first, again)
+			}
+			for _, u := range first {
+				if u[1] < cfg.MinRating || u[1] > cfg.MaxRating {
+					t.Errorf("user %d set to %d, outside %d..%d", u[0], u[1], cfg.MinRating, cfg.MaxRating)
+				}
+			}
+
+			cfg.Seed++
+			if other := play(cfg, 6); slices.Equal(first, other) {
+				t.Errorf("seeds %d and %d played the same updates", cfg.Seed-1, cfg.Seed)
+			}
+		})
+	}
+}
+
+func TestScenarioBursts(t *testing.T) {
+	cfg := testScenario()
+	cfg.BurstEvery, cfg.BurstDuration, cfg.BurstFactor = 3*time.Second, time.Second, 4
+	sc := newScenario(cfg)
+
+	var got []int
+	for range 7 {
+		got = append(got, sc.advance())
+	}
+	// With a tick a second, every third tick is a burst.
+	if want := []int{3, 3, 12, 3, 3, 12, 3}; !slices.Equal(got, want) {
+		t.Errorf("updates per tick = %v, want %v", got, want)
+	}
+}
+
+func TestScenarioPopulation(t *testing.T) {
+	cfg := testScenario()
+	cfg.Population, cfg.PopulationRefresh = 3, 2*time.Second
+
+	drawn := make(map[int]bool)
+	for _, u := range play(cfg, 2) {
+		drawn[u[0]] = true
+	}
+	if len(drawn) > cfg.Population {
+		t.Errorf("updated %d users in the first population, want at most %d", len(drawn), cfg.Population)
+	}
+}
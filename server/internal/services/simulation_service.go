@@ -2,22 +2,162 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/metrics"
 	"leaderboard/internal/repository"
-	"log"
-	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+var simulationLog = logging.Logger("simulation")
+
+// RatingDistribution is how the simulation picks new ratings.
+type RatingDistribution string
+
+const (
+	RatingUniform RatingDistribution = "uniform"
+	// RatingNormal clusters ratings around a mean.
+	RatingNormal RatingDistribution = "normal"
+	// RatingRandomWalk moves ratings up or down from their current value.
+	RatingRandomWalk RatingDistribution = "random_walk"
+	// RatingZipf keeps most ratings near the minimum and few far above it,
+	// like the long tail of a real leaderboard.
+	RatingZipf RatingDistribution = "zipf"
+)
+
+// SimulationConfig is the scenario the simulation plays: every Interval,
+// UpdatesPerTick users drawn from its population get a rating picked from
+// Distribution, between MinRating and MaxRating.
+type SimulationConfig struct {
+	Interval       time.Duration
+	UpdatesPerTick int
+	// Population is how many users are drawn at random from the existing
+	// ones, 0 for all of them. They are drawn again every
+	// PopulationRefresh, unless it is 0, to include new users.
+	Population        int
+	PopulationRefresh time.Duration
+	Distribution      RatingDistribution
+	MinRating         int
+	MaxRating         int
+	// RatingMean is the mean of the normal distribution. RatingStdDev is
+	// its standard deviation, and that of the steps of a random walk.
+	RatingMean   float64
+	RatingStdDev float64
+	// ZipfS is the exponent of the zipf distribution, above 1: the higher,
+	// the fewer users far above MinRating.
+	ZipfS float64
+	// Seed seeds the random numbers of the scenario, so the same seed and
+	// users give the same updates. 0 picks a random seed.
+	Seed int64
+	// Every BurstEvery, unless it is 0, UpdatesPerTick is multiplied by
+	// BurstFactor for BurstDuration.
+	BurstEvery    time.Duration
+	BurstDuration time.Duration
+	BurstFactor   int
+}
+
+// Validate reports the settings that would stop the simulation from
+// running, such as an empty rating range.
+func (c SimulationConfig) Validate() error {
+	var fields []apperr.FieldError
+	invalid := func(field, code, message string) {
+		fields = append(fields, apperr.FieldError{Field: field, Code: code, Message: message})
+	}
+	if c.Interval <= 0 {
+		invalid("interval", "out_of_range", "must be positive")
+	}
+	if c.UpdatesPerTick < 1 {
+		invalid("updates_per_tick", "out_of_range", "must be at least 1")
+	}
+	if c.Population < 0 {
+		invalid("population", "out_of_range", "cannot be negative")
+	}
+	if c.PopulationRefresh < 0 {
+		invalid("population_refresh", "out_of_range", "cannot be negative")
+	}
+	if c.MinRating < 0 || c.MinRating > c.MaxRating {
+		invalid("min_rating", "out_of_range", "must be between 0 and max_rating")
+	}
+	if c.MaxRating > MaxRating {
+		invalid("max_rating", "out_of_range", fmt.Sprintf("cannot be above %d", MaxRating))
+	}
+	switch c.Distribution {
+	case RatingUniform:
+	case RatingNormal, RatingRandomWalk:
+		if c.Distribution == RatingNormal && (c.RatingMean < float64(c.MinRating) || c.RatingMean > float64(c.MaxRating)) {
+			invalid("rating_mean", "out_of_range", "must be between min_rating and max_rating")
+		}
+		if c.RatingStdDev <= 0 {
+			invalid("rating_stddev", "out_of_range", "must be positive")
+		}
+	case RatingZipf:
+		if c.ZipfS <= 1 {
+			invalid("zipf_s", "out_of_range", "must be greater than 1")
+		}
+	default:
+		invalid("distribution", "invalid_choice", "must be uniform, normal, random_walk or zipf")
+	}
+	if c.BurstEvery < 0 {
+		invalid("burst_every", "out_of_range", "cannot be negative")
+	}
+	if c.BurstEvery > 0 {
+		if c.BurstDuration <= 0 || c.BurstDuration > c.BurstEvery {
+			invalid("burst_duration", "out_of_range", "must be positive and at most burst_every")
+		}
+		if c.BurstFactor < 1 {
+			invalid("burst_factor", "out_of_range", "must be at least 1")
+		}
+	}
+	if fields != nil {
+		return apperr.InvalidFields("simulation_config_invalid", "invalid simulation configuration", fields)
+	}
+	return nil
+}
+
+// SimulationService plays a SimulationConfig against the leaderboard. Its
+// updates are writes like any other, so it pauses while maintenance mode
+// is on rather than have every update turned away.
 type SimulationService struct {
-	userRepo repository.UserRepository
-	cancel   context.CancelFunc
-	running  bool
-	mu       sync.Mutex
+	userRepo    repository.UserRepository
+	maintenance *Maintenance
+	config      atomic.Pointer[SimulationConfig]
+	// reconfigured wakes a running simulation to pick up a new interval.
+	reconfigured chan struct{}
+	cancel       context.CancelFunc
+	// done is closed when the running simulation has stopped.
+	done    chan struct{}
+	running bool
+	mu      sync.Mutex
+	// paused is set while ticks are skipped for maintenance. Only the
+	// running simulation uses it.
+	paused bool
 }
 
-func NewSimulationService(userRepo repository.UserRepository) *SimulationService {
-	return &SimulationService{userRepo: userRepo}
+func NewSimulationService(userRepo repository.UserRepository, maintenance *Maintenance, config SimulationConfig) *SimulationService {
+	s := &SimulationService{userRepo: userRepo, maintenance: maintenance, reconfigured: make(chan struct{}, 1)}
+	s.config.Store(&config)
+	return s
+}
+
+// SetConfig changes the scenario of a running simulation, which starts
+// over on its next tick: its random numbers from the seed and its
+// population drawn again. Setting the same scenario changes nothing.
+func (s *SimulationService) SetConfig(config SimulationConfig) {
+	if old := s.config.Swap(&config); *old == config {
+		return
+	}
+	select {
+	case s.reconfigured <- struct{}{}:
+	default:
+	}
+}
+
+// Config returns how the simulation updates ratings.
+func (s *SimulationService) Config() SimulationConfig {
+	return *s.config.Load()
 }
 
 func (s *SimulationService) Start() {
@@ -30,12 +170,15 @@ func (s *SimulationService) Start() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
+	s.done = make(chan struct{})
 	s.running = true
 
-	go s.run(ctx)
-	log.Println("🚀 Simulation started: Randomly updating user ratings...")
+	go s.run(ctx, s.done)
+	simulationLog.Info("Simulation started")
 }
 
+// Stop stops the simulation, canceling the updates in progress, and waits
+// for them to return.
 func (s *SimulationService) Stop() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -44,36 +187,81 @@ func (s *SimulationService) Stop() {
 		return
 	}
 	s.cancel()
+	<-s.done
 	s.running = false
-	log.Println("🛑 Simulation stopped")
+	simulationLog.Info("Simulation stopped")
 }
 
 func (s *SimulationService) IsRunning() bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	log.Printf("Checking simulation status: %v", s.running)
 	return s.running
 }
 
-func (s *SimulationService) run(ctx context.Context) {
-	ticker := time.NewTicker(500 * time.Millisecond) // Update every 500ms
+func (s *SimulationService) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	sc := newScenario(s.Config())
+	ticker := time.NewTicker(sc.cfg.Interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-s.reconfigured:
+			sc = newScenario(s.Config())
+			ticker.Reset(sc.cfg.Interval)
 		case <-ticker.C:
-			// Update 10 random users every tick for more visible changes
-			for j := 0; j < 10; j++ {
-				randomID := rand.Intn(10000) + 1
-				newRating := rand.Intn(4901) + 100 // 100 to 5000
-
-				err := s.userRepo.UpdateRating(randomID, newRating)
-				if err != nil {
-					log.Printf("Simulation error updating user %d: %v", randomID, err)
-				}
+			s.tick(ctx, sc)
+		}
+	}
+}
+
+// tick makes one batch of rating updates. During maintenance it makes
+// none and the scenario stands still, so it carries on where it left off
+// once maintenance is over.
+func (s *SimulationService) tick(ctx context.Context, sc *scenario) {
+	ctx, span := tracer.Start(ctx, "SimulationService.tick")
+	defer span.End()
+
+	if s.maintenance.Check() != nil {
+		if !s.paused {
+			s.paused = true
+			simulationLog.InfoContext(ctx, "Simulation paused for maintenance")
+		}
+		return
+	}
+	if s.paused {
+		s.paused = false
+		simulationLog.InfoContext(ctx, "Simulation resumed after maintenance")
+	}
+
+	updates := sc.advance()
+	if sc.needsPopulation() {
+		if err := sc.drawPopulation(ctx, s.userRepo); err != nil {
+			if ctx.Err() == nil {
+				simulationLog.WarnContext(ctx, "Simulation failed to list users", "error", err)
 			}
+			return
+		}
+	}
+	if len(sc.population) == 0 {
+		return
+	}
+
+	for j := 0; j < updates && ctx.Err() == nil; j++ {
+		u, newRating := sc.next()
+
+		user, err := s.userRepo.UpdateRating(ctx, u.ID, newRating, 0)
+		switch {
+		case err == nil:
+			u.Rating = user.Rating
+			metrics.SimulationUpdates.WithLabelValues("ok").Inc()
+		case ctx.Err() == nil:
+			// Updates cut short by Stop are not failures.
+			metrics.SimulationUpdates.WithLabelValues("failed").Inc()
+			simulationLog.WarnContext(ctx, "Simulation failed to update rating", "user_id", u.ID, "error", err)
 		}
 	}
 }
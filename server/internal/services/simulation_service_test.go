@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"leaderboard/internal/apperr"
+	"slices"
+	"testing"
+	"time"
+)
+
+func testScenario() SimulationConfig {
+	return SimulationConfig{
+		Interval:       time.Second,
+		UpdatesPerTick: 3,
+		Distribution:   RatingUniform,
+		MinRating:      0,
+		MaxRating:      1000,
+		Seed:           42,
+	}
+}
+
+func newTestSimulation(maintenance *Maintenance) (*SimulationService, *fakeUserRepository) {
+	repo := newFakeUserRepository(map[int]int{1: 100, 2: 200, 3: 300, 4: 400})
+	return NewSimulationService(repo, maintenance, testScenario()), repo
+}
+
+// ratings returns the new ratings of the changes made to repo.
+func ratings(repo *fakeUserRepository) []int {
+	var got []int
+	for _, c := range repo.changes {
+		got = append(got, c.NewRating)
+	}
+	return got
+}
+
+func TestSimulationPausesForMaintenance(t *testing.T) {
+	ctx := context.Background()
+	maintenance := NewMaintenance()
+	s, repo := newTestSimulation(maintenance)
+	sc := newScenario(s.Config())
+
+	maintenance.Set(true, "")
+	for range 3 {
+		s.tick(ctx, sc)
+	}
+	if len(repo.changes) != 0 {
+		t.Fatalf("%d updates during maintenance, want none", len(repo.changes))
+	}
+
+	maintenance.Set(false, "")
+	s.tick(ctx, sc)
+	s.tick(ctx, sc)
+
+	// The scenario stood still, so it carries on as if maintenance never
+	// happened.
+	want, wantRepo := newTestSimulation(NewMaintenance())
+	wantScenario := newScenario(want.Config())
+	want.tick(ctx, wantScenario)
+	want.tick(ctx, wantScenario)
+	if got, want := ratings(repo), ratings(wantRepo); !slices.Equal(got, want) {
+		t.Errorf("ratings after maintenance = %v, want %v", got, want)
+	}
+}
+
+func TestSimulationConfigValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		change     func(c *SimulationConfig)
+		wantFields []string
+	}{
+		{name: "valid", change: func(c *SimulationConfig) {}},
+		{name: "largest rating", change: func(c *SimulationConfig) { c.MaxRating = MaxRating }},
+		{name: "rating too high", change: func(c *SimulationConfig) { c.MaxRating = MaxRating + 1 }, wantFields: []string{"max_rating"}},
+		{name: "empty range", change: func(c *SimulationConfig) { c.MinRating = 2000 }, wantFields: []string{"min_rating"}},
+		{name: "no updates", change: func(c *SimulationConfig) { c.UpdatesPerTick = 0 }, wantFields: []string{"updates_per_tick"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testScenario()
+			tt.change(&cfg)
+			err := cfg.Validate()
+
+			var fields []string
+			if e, ok := apperr.As(err); ok {
+				for _, f := range e.Fields {
+					fields = append(fields, f.Field)
+				}
+			} else if err != nil {
+				t.Fatalf("Validate = %v, want field errors", err)
+			}
+			if !slices.Equal(fields, tt.wantFields) {
+				t.Errorf("invalid fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
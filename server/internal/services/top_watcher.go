@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/events"
+	"leaderboard/internal/logging"
+	"leaderboard/internal/repository"
+	"sync"
+	"sync/atomic"
+)
+
+var topWatcherLog = logging.Logger("topwatcher")
+
+// TopSize is the number of leaderboard entries TopWatcher keeps track of and
+// the largest page long-polling clients can wait on.
+const TopSize = MaxPageSize
+
+var ErrTooManyWaiters = apperr.Unavailable("too_many_waiters", "too many clients waiting for leaderboard changes", nil)
+
+// TopPage is a snapshot of the top of the leaderboard.
+type TopPage struct {
+	Version int64                     `json:"version"`
+	Users   []repository.UserWithRank `json:"users"`
+}
+
+// TopWatcher keeps a snapshot of the top of the leaderboard, refreshed from
+// leaderboard events, and wakes long-polling clients when it changes.
+type TopWatcher struct {
+	userRepo   repository.UserRepository
+	bus        events.Bus
+	maxWaiters int64
+	waiters    atomic.Int64
+
+	mu sync.RWMutex
+	// version is the leaderboard version at which the snapshot was taken
+	// after its last change.
+	version int64
+	page    []repository.UserWithRank
+	// changed is closed and replaced every time the snapshot changes.
+	changed chan struct{}
+	// draining is closed by Drain.
+	draining  chan struct{}
+	drainOnce sync.Once
+
+	// cancel cancels refreshes in progress when the watcher stops.
+	cancel      context.CancelFunc
+	unsubscribe func()
+	done        chan struct{}
+}
+
+func NewTopWatcher(userRepo repository.UserRepository, bus events.Bus, maxWaiters int) *TopWatcher {
+	return &TopWatcher{
+		userRepo:   userRepo,
+		bus:        bus,
+		maxWaiters: int64(maxWaiters),
+		changed:    make(chan struct{}),
+		draining:   make(chan struct{}),
+	}
+}
+
+// Start loads the initial snapshot and begins following leaderboard events.
+func (w *TopWatcher) Start() {
+	ch, unsubscribe := w.bus.Subscribe()
+	w.unsubscribe = unsubscribe
+	w.done = make(chan struct{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	w.refresh(ctx)
+	go w.run(ctx, ch)
+}
+
+func (w *TopWatcher) Stop() {
+	if w.unsubscribe == nil {
+		return
+	}
+	w.cancel()
+	w.unsubscribe()
+	<-w.done
+}
+
+// Drain ends every wait, as if nothing changed in time, and makes new waits
+// end at once, so long polls don't hold up a shutdown.
+func (w *TopWatcher) Drain() {
+	w.drainOnce.Do(func() { close(w.draining) })
+}
+
+// Wait blocks until the first limit entries of the leaderboard differ from
+// what the client saw at version, then returns them. Clients that are
+// already behind get the current page immediately. It returns a nil page
+// when ctx is done first or the watcher is drained.
+func (w *TopWatcher) Wait(ctx context.Context, version int64, limit int) (*TopPage, error) {
+	if limit <= 0 || limit > TopSize {
+		return nil, fmt.Errorf("limit must be between 1 and %d", TopSize)
+	}
+
+	if w.waiters.Add(1) > w.maxWaiters {
+		w.waiters.Add(-1)
+		return nil, ErrTooManyWaiters
+	}
+	defer w.waiters.Add(-1)
+
+	w.mu.RLock()
+	current, page, changed := w.version, w.page, w.changed
+	w.mu.RUnlock()
+
+	if version < current {
+		return newTopPage(current, page, limit), nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil
+		case <-w.draining:
+			return nil, nil
+		case <-changed:
+		}
+
+		w.mu.RLock()
+		current, next := w.version, w.page
+		changed = w.changed
+		w.mu.RUnlock()
+
+		// The snapshot covers TopSize entries; only wake clients whose
+		// part of it actually changed.
+		if !samePage(head(page, limit), head(next, limit)) {
+			return newTopPage(current, next, limit), nil
+		}
+		page = next
+	}
+}
+
+func (w *TopWatcher) run(ctx context.Context, ch <-chan events.Event) {
+	defer close(w.done)
+
+	for e := range ch {
+		relevant := w.affectsTop(e)
+		// Coalesce bursts of events into a single refresh.
+	drain:
+		for {
+			select {
+			case next, ok := <-ch:
+				if !ok {
+					break drain
+				}
+				relevant = relevant || w.affectsTop(next)
+			default:
+				break drain
+			}
+		}
+
+		if relevant {
+			w.refresh(ctx)
+		}
+	}
+}
+
+// affectsTop reports whether an event can change the snapshot: either the
+// user is or was rated at least as high as the lowest entry in it, the
+// leaderboard is smaller than the snapshot, or it was rebuilt.
+func (w *TopWatcher) affectsTop(e events.Event) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if e.Type == events.LeaderboardReset || len(w.page) < TopSize {
+		return true
+	}
+	cutoff := w.page[len(w.page)-1].Rating
+	return e.NewRating >= cutoff || (e.Type == events.RatingUpdated && e.OldRating >= cutoff)
+}
+
+func (w *TopWatcher) refresh(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "TopWatcher.refresh")
+	defer span.End()
+
+	// Read the version first so it never claims more than the page shows.
+	version, err := w.userRepo.GetVersion(ctx)
+	if err != nil {
+		topWatcherLog.ErrorContext(ctx, "Failed to read leaderboard version", "error", err)
+		return
+	}
+	page, err := w.userRepo.GetLeaderboard(ctx, TopSize, 0)
+	if err != nil {
+		topWatcherLog.ErrorContext(ctx, "Failed to refresh top of leaderboard", "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.page != nil && samePage(w.page, page) {
+		return
+	}
+	w.version = version
+	w.page = page
+	close(w.changed)
+	w.changed = make(chan struct{})
+}
+
+func newTopPage(version int64, page []repository.UserWithRank, limit int) *TopPage {
+	users := head(page, limit)
+	if users == nil {
+		users = []repository.UserWithRank{}
+	}
+	return &TopPage{Version: version, Users: users}
+}
+
+func head(page []repository.UserWithRank, n int) []repository.UserWithRank {
+	if len(page) > n {
+		return page[:n]
+	}
+	return page
+}
+
+func samePage(a, b []repository.UserWithRank) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Rating != b[i].Rating || a[i].Rank != b[i].Rank {
+			return false
+		}
+	}
+	return true
+}
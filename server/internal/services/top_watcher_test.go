@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"leaderboard/internal/apperr"
+	"leaderboard/internal/events"
+	"testing"
+	"time"
+)
+
+// topWatcherFixture is a started TopWatcher over TopSize+50 users, rated
+// so that user n is ranked n.
+type topWatcherFixture struct {
+	t       *testing.T
+	repo    *fakeUserRepository
+	bus     events.Bus
+	watcher *TopWatcher
+}
+
+func newTopWatcherFixture(t *testing.T, maxWaiters int) *topWatcherFixture {
+	t.Helper()
+	ratings := make(map[int]int)
+	for id := 1; id <= TopSize+50; id++ {
+		ratings[id] = 10000 - id*10
+	}
+	f := &topWatcherFixture{t: t, repo: newFakeUserRepository(ratings), bus: events.NewInMemoryBus()}
+	f.watcher = NewTopWatcher(f.repo, f.bus, maxWaiters)
+	f.watcher.Start()
+	t.Cleanup(func() {
+		f.watcher.Stop()
+		f.bus.Close()
+	})
+	return f
+}
+
+// update changes the rating of user id and publishes the event for it.
+func (f *topWatcherFixture) update(id, rating int) {
+	f.repo.mu.Lock()
+	old := f.repo.users[id]
+	f.repo.mu.Unlock()
+	f.repo.set(id, rating)
+	version, _ := f.repo.GetVersion(context.Background())
+	f.bus.Publish(context.Background(), events.Event{Type: events.RatingUpdated, Version: version, UserID: id, OldRating: old, NewRating: rating})
+}
+
+// wait runs Wait in the background, returning a channel for its result.
+func (f *topWatcherFixture) wait(ctx context.Context, version int64, limit int) <-chan *TopPage {
+	result := make(chan *TopPage, 1)
+	go func() {
+		page, err := f.watcher.Wait(ctx, version, limit)
+		if err != nil {
+			f.t.Errorf("Wait = %v", err)
+		}
+		result <- page
+	}()
+	for f.watcher.waiters.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	return result
+}
+
+func TestTopWatcherWait(t *testing.T) {
+	tests := []struct {
+		name string
+		// id and rating are the update made while the client waits.
+		id, rating int
+		limit      int
+		wantWake   bool
+		wantFirst  int
+	}{
+		{name: "change in the page", id: 5, rating: 20000, limit: 10, wantWake: true, wantFirst: 5},
+		{name: "user enters the page", id: 120, rating: 20000, limit: 10, wantWake: true, wantFirst: 120},
+		{name: "user leaves the page", id: 1, rating: 0, limit: 10, wantWake: true, wantFirst: 2},
+		{name: "change below the page", id: 50, rating: 9499, limit: 10},
+		{name: "change outside the top", id: TopSize + 20, rating: 100, limit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTopWatcherFixture(t, 10)
+			version, _ := f.repo.GetVersion(context.Background())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+			result := f.wait(ctx, version, tt.limit)
+			f.update(tt.id, tt.rating)
+
+			page := <-result
+			if !tt.wantWake {
+				if page != nil {
+					t.Errorf("woken with %d users at version %d, want no page on timeout", len(page.Users), page.Version)
+				}
+				return
+			}
+			if page == nil {
+				t.Fatal("not woken before the timeout")
+			}
+			if page.Version != version+1 || len(page.Users) != tt.limit || page.Users[0].ID != tt.wantFirst {
+				t.Errorf("got %d users led by user %d at version %d, want %d led by user %d at version %d",
+					len(page.Users), page.Users[0].ID, page.Version, tt.limit, tt.wantFirst, version+1)
+			}
+		})
+	}
+}
+
+func TestTopWatcherBehindClient(t *testing.T) {
+	f := newTopWatcherFixture(t, 10)
+	f.update(1, 20000)
+	for {
+		f.watcher.mu.RLock()
+		version := f.watcher.version
+		f.watcher.mu.RUnlock()
+		if version == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A client that saw version 1 gets the current page without waiting.
+	page, err := f.watcher.Wait(context.Background(), 1, 3)
+	if err != nil || page == nil || page.Version != 2 || len(page.Users) != 3 {
+		t.Fatalf("Wait = %+v, %v, want the current 3 users at version 2", page, err)
+	}
+}
+
+func TestTopWatcherLimit(t *testing.T) {
+	f := newTopWatcherFixture(t, 1)
+	for _, limit := range []int{0, TopSize + 1} {
+		if _, err := f.watcher.Wait(context.Background(), 1, limit); err == nil {
+			t.Errorf("limit %d accepted", limit)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := f.wait(ctx, 1, 10)
+	_, err := f.watcher.Wait(context.Background(), 1, 10)
+	if !errors.Is(err, ErrTooManyWaiters) || apperr.KindOf(err) != apperr.KindUnavailable {
+		t.Errorf("Wait beyond the limit = %v, want ErrTooManyWaiters", err)
+	}
+
+	cancel()
+	<-result
+	// The slot is free again once the first client is gone.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.watcher.Wait(ctx, 1, 10); err != nil {
+		t.Errorf("Wait after the first client left = %v", err)
+	}
+}
+
+func TestTopWatcherDrain(t *testing.T) {
+	f := newTopWatcherFixture(t, 10)
+	result := f.wait(context.Background(), 1, 10)
+
+	f.watcher.Drain()
+	select {
+	case page := <-result:
+		if page != nil {
+			t.Errorf("drained wait returned %d users, want none", len(page.Users))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Drain did not release the waiting client")
+	}
+
+	f.watcher.Drain() // draining twice is harmless
+	page, err := f.watcher.Wait(context.Background(), 1, 10)
+	if page != nil || err != nil {
+		t.Errorf("Wait after Drain = %+v, %v, want it to end at once", page, err)
+	}
+}
+
+func TestTopWatcherAffectsTop(t *testing.T) {
+	f := newTopWatcherFixture(t, 10)
+	// The lowest entry of the snapshot is user TopSize.
+	cutoff := 10000 - TopSize*10
+
+	tests := []struct {
+		name string
+		e    events.Event
+		want bool
+	}{
+		{name: "rises into the top", e: events.Event{Type: events.RatingUpdated, OldRating: 0, NewRating: cutoff + 1}, want: true},
+		{name: "ties the lowest entry", e: events.Event{Type: events.RatingUpdated, OldRating: 0, NewRating: cutoff}, want: true},
+		{name: "falls out of the top", e: events.Event{Type: events.RatingUpdated, OldRating: cutoff + 5, NewRating: 0}, want: true},
+		{name: "stays below", e: events.Event{Type: events.RatingUpdated, OldRating: 10, NewRating: cutoff - 1}},
+		{name: "created below", e: events.Event{Type: events.UserCreated, OldRating: cutoff + 5, NewRating: 10}},
+		{name: "created in the top", e: events.Event{Type: events.UserCreated, NewRating: cutoff + 5}, want: true},
+		{name: "reset", e: events.Event{Type: events.LeaderboardReset}, want: true},
+	}
+	for _, tt := range tests {
+		if got := f.watcher.affectsTop(tt.e); got != tt.want {
+			t.Errorf("%s: affectsTop = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
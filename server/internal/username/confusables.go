@@ -0,0 +1,49 @@
+package username
+
+import "unicode"
+
+// confusables maps lowercase letters of other scripts that are commonly
+// mistaken for Latin letters to those letters, a small subset of the
+// Unicode confusables data (UTS #39) covering the scripts of
+// confusableScriptTables.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'һ': 'h', 'і': 'i', 'ј': 'j',
+	'к': 'k', 'ӏ': 'l', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'ѕ': 's', 'у': 'y',
+	'ԝ': 'w', 'х': 'x',
+	// Greek
+	'α': 'a', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'υ': 'u',
+	'χ': 'x',
+	// Armenian
+	'ց': 'g', 'հ': 'h', 'ո': 'n', 'օ': 'o', 'զ': 'q', 'ս': 'u',
+	// Latin letters that look like other Latin letters
+	'ı': 'i', 'ɡ': 'g', 'ɑ': 'a',
+}
+
+// confusableScriptTables are scripts whose letters are easily mistaken for
+// each other. A username may use any one of them, but mixing them is how
+// lookalikes of other usernames are made.
+var confusableScriptTables = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Greek", unicode.Greek},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Armenian", unicode.Armenian},
+}
+
+// confusableScripts returns the names of the confusable scripts used in
+// name, in the order of confusableScriptTables.
+func confusableScripts(name string) []string {
+	var found []string
+	for _, s := range confusableScriptTables {
+		for _, r := range name {
+			if unicode.Is(s.table, r) {
+				found = append(found, s.name)
+				break
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,195 @@
+// Package username validates and normalizes usernames.
+package username
+
+import (
+	"fmt"
+	"leaderboard/internal/apperr"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Character classes a policy can allow.
+const (
+	ClassLetters      = "letters"       // letters of any script
+	ClassASCIILetters = "ascii-letters" // a-z and A-Z only
+	ClassDigits       = "digits"
+	ClassUnderscore   = "underscore"
+	ClassHyphen       = "hyphen"
+	ClassPeriod       = "period"
+)
+
+var knownClasses = []string{ClassLetters, ClassASCIILetters, ClassDigits, ClassUnderscore, ClassHyphen, ClassPeriod}
+
+// Policy is the set of rules usernames must follow.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// AllowedClasses lists the character classes usernames may contain.
+	AllowedClasses []string
+	// Reserved names can't be taken by anyone, compared after
+	// normalization.
+	Reserved []string
+	// Blocked words may not appear anywhere in a username.
+	Blocked []string
+}
+
+// DefaultPolicy allows 3 to 20 letters, digits, underscores, hyphens and
+// periods, and reserves names that could be mistaken for staff.
+func DefaultPolicy() Policy {
+	return Policy{
+		MinLength:      3,
+		MaxLength:      20,
+		AllowedClasses: []string{ClassLetters, ClassDigits, ClassUnderscore, ClassHyphen, ClassPeriod},
+		Reserved:       []string{"admin", "administrator", "root", "system", "support", "moderator", "staff", "me", "null", "undefined"},
+	}
+}
+
+// Validate checks the policy itself.
+func (p Policy) Validate() error {
+	if p.MinLength < 1 {
+		return fmt.Errorf("minimum length must be at least 1, got %d", p.MinLength)
+	}
+	if p.MaxLength < p.MinLength {
+		return fmt.Errorf("maximum length %d is below the minimum length %d", p.MaxLength, p.MinLength)
+	}
+	if len(p.AllowedClasses) == 0 {
+		return fmt.Errorf("at least one character class must be allowed")
+	}
+	for _, c := range p.AllowedClasses {
+		if !slices.Contains(knownClasses, c) {
+			return fmt.Errorf("unknown character class %q, expected one of %v", c, knownClasses)
+		}
+	}
+	return nil
+}
+
+// Normalize validates a username and returns the form to store and show,
+// NFKC normalized, and its canonical form. Two usernames with the same
+// canonical form differ only in case or in characters that look alike, so
+// only one of them may be registered.
+func (p Policy) Normalize(raw string) (display, canonical string, err error) {
+	display = norm.NFKC.String(strings.TrimSpace(raw))
+	canonical = Canonical(display)
+
+	var problems []apperr.FieldError
+	add := func(code, format string, args ...any) {
+		problems = append(problems, apperr.FieldError{Field: "username", Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch n := utf8.RuneCountInString(display); {
+	case n < p.MinLength:
+		add("too_short", "must be at least %d characters", p.MinLength)
+	case n > p.MaxLength:
+		add("too_long", "must be at most %d characters", p.MaxLength)
+	}
+
+	if bad := p.disallowed(display); len(bad) > 0 {
+		add("invalid_characters", "contains characters that are not allowed: %s", quoteRunes(bad))
+	} else if display != "" {
+		first, _ := utf8.DecodeRuneInString(display)
+		last, _ := utf8.DecodeLastRuneInString(display)
+		if !isAlphanumeric(first) || !isAlphanumeric(last) {
+			add("invalid_boundary", "must start and end with a letter or digit")
+		}
+	}
+
+	if scripts := confusableScripts(display); len(scripts) > 1 {
+		add("mixed_scripts", "mixes characters of the %s scripts, which can look alike", strings.Join(scripts, " and "))
+	}
+
+	for _, word := range p.Reserved {
+		if canonical == Canonical(word) {
+			add("reserved", "is reserved")
+			break
+		}
+	}
+	for _, word := range p.Blocked {
+		if w := Canonical(word); w != "" && strings.Contains(canonical, w) {
+			add("blocked_word", "contains a word that is not allowed")
+			break
+		}
+	}
+
+	if len(problems) > 0 {
+		return "", "", apperr.InvalidFields("username_invalid", "username does not meet the username policy", problems)
+	}
+	return display, canonical, nil
+}
+
+// Canonical returns the form of a username used to decide whether two
+// usernames are the same: NFKC normalized, case folded, and with characters
+// that look like Latin letters replaced by them.
+func Canonical(name string) string {
+	folded := cases.Fold().String(norm.NFKC.String(name))
+
+	var b strings.Builder
+	for _, r := range folded {
+		if latin, ok := confusables[r]; ok {
+			r = latin
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// disallowed returns the distinct characters of name outside the allowed
+// classes.
+func (p Policy) disallowed(name string) []rune {
+	var bad []rune
+	for _, r := range name {
+		if !p.allows(r) && !slices.Contains(bad, r) {
+			bad = append(bad, r)
+		}
+	}
+	return bad
+}
+
+func (p Policy) allows(r rune) bool {
+	for _, class := range p.AllowedClasses {
+		switch class {
+		case ClassLetters:
+			// Combining marks are part of letters in many scripts.
+			if unicode.IsLetter(r) || unicode.Is(unicode.Mn, r) {
+				return true
+			}
+		case ClassASCIILetters:
+			if r < utf8.RuneSelf && unicode.IsLetter(r) {
+				return true
+			}
+		case ClassDigits:
+			if unicode.IsDigit(r) {
+				return true
+			}
+		case ClassUnderscore:
+			if r == '_' {
+				return true
+			}
+		case ClassHyphen:
+			if r == '-' {
+				return true
+			}
+		case ClassPeriod:
+			if r == '.' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isAlphanumeric(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func quoteRunes(runes []rune) string {
+	quoted := make([]string, len(runes))
+	for i, r := range runes {
+		quoted[i] = fmt.Sprintf("%q", r)
+	}
+	return strings.Join(quoted, ", ")
+}
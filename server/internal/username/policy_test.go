@@ -0,0 +1,110 @@
+package username
+
+import (
+	"leaderboard/internal/apperr"
+	"slices"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Blocked = []string{"badword"}
+
+	tests := []struct {
+		name      string
+		raw       string
+		display   string
+		canonical string
+		codes     []string
+	}{
+		{name: "plain", raw: "player_1", display: "player_1", canonical: "player_1"},
+		{name: "case folded", raw: "Player.One", display: "Player.One", canonical: "player.one"},
+		{name: "trimmed", raw: "  alice  ", display: "alice", canonical: "alice"},
+		{name: "fullwidth", raw: "ｆｏｏｂａｒ", display: "foobar", canonical: "foobar"},
+		{name: "greek and latin", raw: "Ωmega", codes: []string{"mixed_scripts"}},
+		{name: "greek", raw: "αβγδ", display: "αβγδ", canonical: "aβγδ"},
+		{name: "too short", raw: "ab", codes: []string{"too_short"}},
+		{name: "too long", raw: "abcdefghijklmnopqrstu", codes: []string{"too_long"}},
+		{name: "invalid characters", raw: "bad name!", codes: []string{"invalid_characters"}},
+		{name: "boundary", raw: "_bad", codes: []string{"invalid_boundary"}},
+		{name: "mixed scripts", raw: "pаypal", codes: []string{"mixed_scripts"}},
+		{name: "reserved", raw: "Admin", codes: []string{"reserved"}},
+		{name: "reserved lookalike", raw: "аdmіn", codes: []string{"mixed_scripts", "reserved"}},
+		{name: "blocked", raw: "xBadWordx", codes: []string{"blocked_word"}},
+		{name: "several problems", raw: "a!", codes: []string{"too_short", "invalid_characters"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display, canonical, err := policy.Normalize(tt.raw)
+			if len(tt.codes) == 0 {
+				if err != nil {
+					t.Fatalf("Normalize(%q) failed: %v", tt.raw, err)
+				}
+				if display != tt.display || canonical != tt.canonical {
+					t.Errorf("Normalize(%q) = %q, %q, want %q, %q", tt.raw, display, canonical, tt.display, tt.canonical)
+				}
+				return
+			}
+
+			e, ok := apperr.As(err)
+			if !ok || e.Kind != apperr.KindValidation {
+				t.Fatalf("Normalize(%q) error = %v, want a validation error", tt.raw, err)
+			}
+			var codes []string
+			for _, f := range e.Fields {
+				codes = append(codes, f.Code)
+			}
+			if !slices.Equal(codes, tt.codes) {
+				t.Errorf("Normalize(%q) problems = %v, want %v", tt.raw, codes, tt.codes)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"Alice", "alice", true},
+		{"alice", "аlice", true}, // Cyrillic а
+		{"straße", "STRASSE", true},
+		{"ｂｏｂ", "bob", true},
+		{"alice", "alicia", false},
+	}
+
+	for _, tt := range tests {
+		if got := Canonical(tt.a) == Canonical(tt.b); got != tt.same {
+			t.Errorf("Canonical(%q) == Canonical(%q) is %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	valid := DefaultPolicy()
+
+	tests := []struct {
+		name    string
+		change  func(p *Policy)
+		wantErr bool
+	}{
+		{name: "default", change: func(p *Policy) {}},
+		{name: "zero minimum", change: func(p *Policy) { p.MinLength = 0 }, wantErr: true},
+		{name: "maximum below minimum", change: func(p *Policy) { p.MaxLength = 2 }, wantErr: true},
+		{name: "no classes", change: func(p *Policy) { p.AllowedClasses = nil }, wantErr: true},
+		{name: "unknown class", change: func(p *Policy) { p.AllowedClasses = []string{"emoji"} }, wantErr: true},
+		{name: "ascii only", change: func(p *Policy) { p.AllowedClasses = []string{ClassASCIILetters} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			p.AllowedClasses = slices.Clone(valid.AllowedClasses)
+			tt.change(&p)
+			if err := p.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}